@@ -0,0 +1,84 @@
+package compressionstdlib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// algorithmNames holds the canonical name of each algorithm
+var algorithmNames = map[Algorithm]string{
+	Gzip:   "gzip",
+	Zlib:   "zlib",
+	Flate:  "flate",
+	Bzip2:  "bzip2",
+	Zstd:   "zstd",
+	LZ4:    "lz4",
+	Brotli: "brotli",
+	Snappy: "snappy",
+	S2:     "s2",
+	XZ:     "xz",
+	None:   "none",
+	Auto:   "auto",
+	RLE:    "rle",
+	LZW:    "lzw",
+}
+
+// algorithmAliases holds alternative names accepted by ParseAlgorithm, such as HTTP content codings
+var algorithmAliases = map[string]Algorithm{
+	"deflate":  Flate,
+	"br":       Brotli,
+	"identity": None,
+}
+
+// AlgorithmNames returns the canonical names accepted by ParseAlgorithm, in
+// the order of the Algorithm constants
+func AlgorithmNames() []string {
+	names := make([]string, 0, len(algorithmNames))
+	for a := Gzip; int(a) < len(algorithmNames); a++ {
+		names = append(names, algorithmNames[a])
+	}
+	return names
+}
+
+// ParseAlgorithm returns the algorithm with the given name. Names are case
+// insensitive; besides the canonical names, "deflate", "br" and "identity" are
+// accepted as in HTTP content codings.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for a, n := range algorithmNames {
+		if n == name {
+			return a, nil
+		}
+	}
+	if a, ok := algorithmAliases[name]; ok {
+		return a, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, name)
+}
+
+// String returns the canonical name of the algorithm
+func (a Algorithm) String() string {
+	if name, ok := algorithmNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(a))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (a Algorithm) MarshalText() ([]byte, error) {
+	name, ok := algorithmNames[a]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, a)
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the names of ParseAlgorithm
+func (a *Algorithm) UnmarshalText(text []byte) error {
+	parsed, err := ParseAlgorithm(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
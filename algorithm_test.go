@@ -0,0 +1,95 @@
+package compressionstdlib
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+)
+
+func TestParseAlgorithm(t *testing.T) {
+	tests := []struct {
+		name string
+		want Algorithm
+	}{
+		{"gzip", Gzip},
+		{"ZLIB", Zlib},
+		{" flate ", Flate},
+		{"deflate", Flate},
+		{"br", Brotli},
+		{"identity", None},
+		{"lzw", LZW},
+	}
+	for _, tt := range tests {
+		got, err := ParseAlgorithm(tt.name)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%q: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := ParseAlgorithm("lzma"); err == nil {
+		t.Fatal("Expected error for unknown algorithm")
+	}
+}
+
+func TestAlgorithmNames(t *testing.T) {
+	names := AlgorithmNames()
+	if len(names) != len(algorithmNames) {
+		t.Fatalf("Expected %d names, got %d", len(algorithmNames), len(names))
+	}
+	for i, name := range names {
+		a, err := ParseAlgorithm(name)
+		if err != nil || a != Algorithm(i) {
+			t.Fatalf("Name %q does not parse back to algorithm %d: %v", name, i, err)
+		}
+	}
+}
+
+func TestAlgorithmText(t *testing.T) {
+	if s := Zlib.String(); s != "zlib" {
+		t.Fatalf("Expected %q, got %q", "zlib", s)
+	}
+	if s := Algorithm(99).String(); s != "Algorithm(99)" {
+		t.Fatalf("Expected %q, got %q", "Algorithm(99)", s)
+	}
+
+	type config struct {
+		Algorithm Algorithm `json:"algorithm"`
+	}
+	data, err := json.Marshal(config{Algorithm: Brotli})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if string(data) != `{"algorithm":"brotli"}` {
+		t.Fatalf("Unexpected JSON: %s", data)
+	}
+
+	var c config
+	if err := json.Unmarshal([]byte(`{"algorithm":"deflate"}`), &c); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if c.Algorithm != Flate {
+		t.Fatalf("Expected Flate, got %v", c.Algorithm)
+	}
+
+	if err := json.Unmarshal([]byte(`{"algorithm":"unknown"}`), &c); err == nil {
+		t.Fatal("Expected error for unknown algorithm name")
+	}
+	if _, err := json.Marshal(config{Algorithm: Algorithm(99)}); err == nil {
+		t.Fatal("Expected error marshaling unknown algorithm")
+	}
+}
+
+func TestAlgorithmFlag(t *testing.T) {
+	alg := Gzip
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.TextVar(&alg, "compression", Gzip, "compression algorithm")
+	if err := fs.Parse([]string{"-compression=xz"}); err != nil {
+		t.Fatalf("Failed to parse flag: %v", err)
+	}
+	if alg != XZ {
+		t.Fatalf("Expected XZ, got %v", alg)
+	}
+}
@@ -0,0 +1,144 @@
+package compressionstdlib
+
+import (
+	"encoding/ascii85"
+	"encoding/base64"
+	"io"
+)
+
+// Armor selects a text encoding applied to the compressed stream
+type Armor int
+
+const (
+	// ArmorNone leaves the compressed stream binary
+	ArmorNone Armor = iota
+	// ArmorBase64 encodes the compressed stream as standard base64
+	ArmorBase64
+	// ArmorASCII85 encodes the compressed stream as ascii85, which is denser than base64
+	ArmorASCII85
+)
+
+// WithArmor encodes the compressed output as text after compression and decodes
+// it before decompression, so compressed buffers can travel through text-only
+// transports such as email, JSON fields or YAML manifests. Output lines are
+// wrapped at lineWidth characters; a lineWidth <= 0 disables wrapping.
+// Whitespace in the input is ignored when reading. Flush emits complete
+// encoding groups only: the last 1-2 (base64) or 1-3 (ascii85) compressed
+// bytes stay buffered until more data follows or the writer is closed.
+func WithArmor(armor Armor, lineWidth int) Option {
+	return func(m *Middleware) {
+		if armor >= ArmorNone && armor <= ArmorASCII85 {
+			m.armor = armor
+			m.armorWidth = max(lineWidth, 0)
+		}
+	}
+}
+
+// armorEncoder returns the encoder writing armored text to w
+func (m *Middleware) armorEncoder(w io.Writer) (io.WriteCloser, *lineWriter) {
+	lw := &lineWriter{w: w, width: m.armorWidth}
+	if m.armor == ArmorASCII85 {
+		return ascii85.NewEncoder(lw), lw
+	}
+	return base64.NewEncoder(base64.StdEncoding, lw), lw
+}
+
+// armorDecoder returns a reader decoding armored text from r
+func (m *Middleware) armorDecoder(r io.Reader) io.Reader {
+	if m.armor == ArmorASCII85 {
+		return ascii85.NewDecoder(r)
+	}
+	// The base64 decoder skips line breaks but no other whitespace
+	return base64.NewDecoder(base64.StdEncoding, &whitespaceFilter{r: r})
+}
+
+// armoredWriter finalizes the armor encoding after the compressed stream is closed
+type armoredWriter struct {
+	io.Writer
+	enc io.WriteCloser
+	lw  *lineWriter
+}
+
+// Flush flushes the compressed stream through the encoder to the destination.
+// A trailing partial encoding group cannot be emitted without ending the
+// encoding, so it stays buffered until the next group completes or Close.
+func (w *armoredWriter) Flush() error {
+	if err := flush(w.Writer); err != nil {
+		return err
+	}
+	return flush(w.lw.w)
+}
+
+func (w *armoredWriter) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	if err := w.enc.Close(); err != nil {
+		return err
+	}
+	return w.lw.finish()
+}
+
+// lineWriter inserts a newline every width bytes
+type lineWriter struct {
+	w     io.Writer
+	width int
+	col   int
+}
+
+func (w *lineWriter) Write(p []byte) (n int, err error) {
+	if w.width <= 0 {
+		return w.w.Write(p)
+	}
+	for len(p) > 0 {
+		chunk := p[:min(len(p), w.width-w.col)]
+		c, err := w.w.Write(chunk)
+		n += c
+		w.col += c
+		if err != nil {
+			return n, err
+		}
+		p = p[c:]
+		if w.col == w.width {
+			if _, err := w.w.Write([]byte{'\n'}); err != nil {
+				return n, err
+			}
+			w.col = 0
+		}
+	}
+	return n, nil
+}
+
+// finish terminates the last line
+func (w *lineWriter) finish() error {
+	if w.width <= 0 || w.col == 0 {
+		return nil
+	}
+	w.col = 0
+	_, err := w.w.Write([]byte{'\n'})
+	return err
+}
+
+// whitespaceFilter drops ASCII whitespace from the stream
+type whitespaceFilter struct {
+	r io.Reader
+}
+
+func (f *whitespaceFilter) Read(p []byte) (n int, err error) {
+	for n == 0 && err == nil {
+		n, err = f.r.Read(p)
+		j := 0
+		for _, b := range p[:n] {
+			switch b {
+			case ' ', '\t', '\n', '\r', '\v', '\f':
+			default:
+				p[j] = b
+				j++
+			}
+		}
+		n = j
+	}
+	return n, err
+}
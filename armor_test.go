@@ -0,0 +1,89 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"encoding/ascii85"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestArmor(t *testing.T) {
+	data := bytes.Repeat([]byte("armored payload for text-only transports "), 200)
+
+	for _, armor := range []Armor{ArmorBase64, ArmorASCII85} {
+		for _, width := range []int{0, 64, 76} {
+			m := New(Gzip, WithArmor(armor, width))
+			compressed := compressWith(t, m, data)
+
+			for _, line := range strings.Split(strings.TrimSuffix(string(compressed), "\n"), "\n") {
+				if width > 0 && len(line) > width {
+					t.Fatalf("Armor %d: Line exceeds width %d: %d", armor, width, len(line))
+				}
+				for _, c := range []byte(line) {
+					if c < 0x21 || c > 0x7e {
+						t.Fatalf("Armor %d: Non-printable byte %#x in output", armor, c)
+					}
+				}
+			}
+
+			got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Fatalf("Armor %d, width %d: Failed to read: %v", armor, width, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("Armor %d, width %d: Data mismatch", armor, width)
+			}
+		}
+	}
+}
+
+func TestArmor_IndentedInput(t *testing.T) {
+	m := New(Zlib, WithArmor(ArmorBase64, 20))
+	compressed := compressWith(t, m, []byte("embedded in a YAML manifest"))
+
+	// Simulate an indented YAML block scalar
+	indented := "  " + strings.ReplaceAll(string(compressed), "\n", "\n  ")
+	got, err := io.ReadAll(m.Reader(strings.NewReader(indented)))
+	if err != nil {
+		t.Fatalf("Failed to read indented armor: %v", err)
+	}
+	if string(got) != "embedded in a YAML manifest" {
+		t.Fatalf("Unexpected data: %q", got)
+	}
+}
+
+func TestArmor_Flush(t *testing.T) {
+	data := []byte("flushed through the armor")
+
+	// The compressed bytes a flush produces without armor
+	var plain bytes.Buffer
+	pw := New(Gzip).Writer(&plain)
+	pw.Write(data)
+	if err := flush(pw); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	for armor, group := range map[Armor]int{ArmorBase64: 3, ArmorASCII85: 4} {
+		var buf bytes.Buffer
+		w := New(Gzip, WithArmor(armor, 0)).Writer(&buf)
+		w.Write(data)
+		if err := flush(w); err != nil {
+			t.Fatalf("Armor %d: Flush failed: %v", armor, err)
+		}
+
+		var decoded []byte
+		if armor == ArmorBase64 {
+			decoded, _ = base64.StdEncoding.DecodeString(buf.String())
+		} else {
+			decoded = make([]byte, buf.Len())
+			n, _, _ := ascii85.Decode(decoded, buf.Bytes(), true)
+			decoded = decoded[:n]
+		}
+		// Only a trailing partial group may remain buffered
+		if !bytes.HasPrefix(plain.Bytes(), decoded) || plain.Len()-len(decoded) >= group {
+			t.Fatalf("Armor %d: Expected %d flushed bytes up to a partial group, got %d", armor, plain.Len(), len(decoded))
+		}
+	}
+}
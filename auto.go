@@ -0,0 +1,53 @@
+package compressionstdlib
+
+import (
+	"compress/flate"
+	"io"
+)
+
+// autoStoreRatio is the compressed to original size ratio of the sample above
+// which Auto stores a stream uncompressed
+const autoStoreRatio = 0.9
+
+// WithSampleSize sets how many bytes are buffered before the algorithm or level
+// is selected by Auto, NewLazy, WithAutoLevel, WithMinRatio or WithMinSize.
+// The default is 4KB.
+func WithSampleSize(n int) Option {
+	return func(m *Middleware) {
+		if n > 0 {
+			m.sampleSize = n
+		}
+	}
+}
+
+// sampleLimit returns the amount of data buffered before the algorithm is selected
+func (m *Middleware) sampleLimit() int {
+	if m.sampleSize > 0 {
+		return max(m.sampleSize, m.minSize)
+	}
+	return max(lazySniffSize, m.minSize)
+}
+
+// measureSample selects the algorithm for Auto by compressing the sample at
+// the fastest level: data that barely shrinks is stored, streams that fit
+// entirely in the sample use raw deflate to avoid the gzip framing overhead,
+// and everything else is compressed with gzip at the configured level.
+func (m *Middleware) measureSample(sample []byte) (Algorithm, int) {
+	if len(sample) == 0 {
+		return Flate, m.level
+	}
+
+	cw := &countingWriter{w: io.Discard}
+	fw, _ := flate.NewWriter(cw, flate.BestSpeed)
+	fw.Write(sample)
+	fw.Close()
+
+	switch {
+	case float64(cw.n) > autoStoreRatio*float64(len(sample)):
+		return None, 0
+	case len(sample) < m.sampleLimit():
+		return Flate, m.level
+	default:
+		return Gzip, m.level
+	}
+}
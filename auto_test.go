@@ -0,0 +1,53 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestAutoSelection(t *testing.T) {
+	noise := make([]byte, 20000)
+	rand.New(rand.NewSource(1)).Read(noise)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		algorithm Algorithm
+	}{
+		{"compressible", bytes.Repeat([]byte("log line with some text\n"), 1000), Gzip},
+		{"incompressible", noise, None},
+		{"short", bytes.Repeat([]byte("short "), 50), Flate},
+		{"tiny", []byte("tiny"), None},
+		{"empty", nil, Flate},
+	}
+
+	m := New(Auto)
+	for _, tt := range tests {
+		compressed := compressWith(t, m, tt.data)
+		if len(compressed) < streamHeaderSize {
+			t.Fatalf("%s: Missing stream header", tt.name)
+		}
+		if algorithm := Algorithm(compressed[5]); algorithm != tt.algorithm {
+			t.Fatalf("%s: Expected algorithm %d, got %d", tt.name, tt.algorithm, algorithm)
+		}
+
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%s: Failed to read: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, tt.data) {
+			t.Fatalf("%s: Data mismatch", tt.name)
+		}
+	}
+}
+
+func TestAutoSampleSize(t *testing.T) {
+	// A stream longer than the sample is compressed with gzip instead of raw deflate
+	data := bytes.Repeat([]byte("sampled "), 100)
+	compressed := compressWith(t, New(Auto, WithSampleSize(256)), data)
+	if algorithm := Algorithm(compressed[5]); algorithm != Gzip {
+		t.Fatalf("Expected gzip for stream exceeding the sample, got %d", algorithm)
+	}
+}
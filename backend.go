@@ -0,0 +1,105 @@
+package compressionstdlib
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Backend selects the deflate implementation used to compress gzip, zlib and
+// flate streams. All backends produce the same wire format, so streams are
+// always decoded with the standard library.
+type Backend int
+
+const (
+	// BackendStdlib compresses with the standard library
+	BackendStdlib Backend = iota
+	// BackendKlauspost compresses with github.com/klauspost/compress, which is
+	// considerably faster. It requires building with -tags klauspost.
+	BackendKlauspost
+)
+
+// backendNames holds the name of each backend
+var backendNames = map[Backend]string{
+	BackendStdlib:    "stdlib",
+	BackendKlauspost: "klauspost",
+}
+
+// String returns the name of the backend
+func (b Backend) String() string {
+	if name, ok := backendNames[b]; ok {
+		return name
+	}
+	return fmt.Sprintf("Backend(%d)", int(b))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (b Backend) MarshalText() ([]byte, error) {
+	name, ok := backendNames[b]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown backend %v", ErrInvalidOption, b)
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the backend
+// names case insensitively
+func (b *Backend) UnmarshalText(text []byte) error {
+	name := strings.ToLower(strings.TrimSpace(string(text)))
+	for backend, n := range backendNames {
+		if n == name {
+			*b = backend
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown backend %q", ErrInvalidOption, text)
+}
+
+// backendCodecs holds the alternative deflate implementations compiled into this build
+var backendCodecs = map[Backend]map[Algorithm]codec{}
+
+func registerBackend(backend Backend, algorithm Algorithm, c codec) {
+	if backendCodecs[backend] == nil {
+		backendCodecs[backend] = map[Algorithm]codec{}
+	}
+	backendCodecs[backend][algorithm] = c
+}
+
+// WithBackend selects the deflate implementation used for compression
+func WithBackend(backend Backend) Option {
+	return func(m *Middleware) {
+		m.backend = backend
+	}
+}
+
+// backendWriter creates the compressor of the selected backend. It reports
+// false when the standard library is used.
+func (m *Middleware) backendWriter(w io.Writer) (io.Writer, bool) {
+	if m.backend == BackendStdlib {
+		return nil, false
+	}
+	// Only the standard library writer exposes the gzip header
+	if (m.deterministic || m.originalSize) && m.algorithm == Gzip {
+		return nil, false
+	}
+	switch m.algorithm {
+	case Gzip:
+	case Zlib, Flate:
+		// Preset dictionaries are only supported by the standard library writer
+		if m.seeds != nil || m.dictionary != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	c, ok := backendCodecs[m.backend][m.algorithm]
+	if !ok {
+		panic("unsupported compression backend: build with -tags klauspost")
+	}
+	cw, err := c.newWriter(w, m.level)
+	if err != nil {
+		panic("failed to create compressor: " + err.Error())
+	}
+	return cw, true
+}
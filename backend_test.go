@@ -0,0 +1,39 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBackendRoundTrip(t *testing.T) {
+	if len(backendCodecs) == 0 {
+		t.Skip("no alternative backends compiled in; run with -tags klauspost")
+	}
+	data := bytes.Repeat([]byte("alternative deflate backend "), 1000)
+
+	for backend, algorithms := range backendCodecs {
+		for algorithm := range algorithms {
+			m := New(algorithm, WithBackend(backend))
+			got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+			if err != nil {
+				t.Fatalf("Backend %d algorithm %d: read failed: %v", backend, algorithm, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("Backend %d algorithm %d: data mismatch", backend, algorithm)
+			}
+		}
+	}
+}
+
+func TestBackendNotCompiledIn(t *testing.T) {
+	if _, ok := backendCodecs[BackendKlauspost]; ok {
+		t.Skip("klauspost backend compiled in")
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected panic for backend missing from the build")
+		}
+	}()
+	New(Gzip, WithBackend(BackendKlauspost)).Writer(&bytes.Buffer{})
+}
@@ -0,0 +1,261 @@
+// Package backfill retrofits compression onto existing uncompressed HybridBuffer
+// spill files.
+//
+// Every file is compressed through a middleware into a temporary file next to
+// it, verified by decompressing it again and comparing checksums, and then
+// atomically renamed over the original. An optional journal records each
+// rename before it happens and each completed file after it, so an
+// interrupted run can be resumed without compressing a file twice.
+package backfill
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"schneider.vip/hybridbuffer/middleware"
+)
+
+// TempSuffix is appended to a file's path to name its temporary compressed copy
+const TempSuffix = ".hbcompress.tmp"
+
+// pendingPrefix marks journal lines recording a rename that is about to happen
+const pendingPrefix = "pending\t"
+
+// ErrVerificationFailed is returned when a compressed file does not decompress to the original data
+var ErrVerificationFailed = errors.New("backfill: round-trip verification failed")
+
+// Progress describes the state of a backfill run after a file has been processed
+type Progress struct {
+	Path     string
+	Done     int
+	Total    int
+	Skipped  bool  // file was already completed in a previous run
+	BytesIn  int64 // uncompressed size of the file
+	BytesOut int64 // compressed size of the file
+}
+
+// Option configures a backfill run
+type Option func(*config)
+
+type config struct {
+	journal  string
+	progress func(Progress)
+}
+
+// WithJournal records completed files in the given journal file. Files listed
+// in the journal are skipped, which allows resuming an interrupted run.
+func WithJournal(path string) Option {
+	return func(c *config) {
+		c.journal = path
+	}
+}
+
+// WithProgress registers a callback invoked after each file
+func WithProgress(fn func(Progress)) Option {
+	return func(c *config) {
+		c.progress = fn
+	}
+}
+
+// Run compresses the given files in place through m
+func Run(ctx context.Context, m middleware.Middleware, paths []string, opts ...Option) error {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	completed, pending, err := loadJournal(c.journal)
+	if err != nil {
+		return err
+	}
+
+	var journal *os.File
+	if c.journal != "" {
+		journal, err = os.OpenFile(c.journal, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("backfill: failed to open journal: %w", err)
+		}
+		defer journal.Close()
+	}
+
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		p := Progress{Path: path, Done: i + 1, Total: len(paths)}
+		switch {
+		case completed[path]:
+			// A crash may have left a temporary file behind after the rename
+			os.Remove(path + TempSuffix)
+			p.Skipped = true
+		case pending[path]:
+			// The verified temporary file is gone once the rename happened;
+			// otherwise finish the interrupted rename
+			info, err := os.Stat(path + TempSuffix)
+			if err == nil {
+				p.BytesOut = info.Size()
+				err = replace(path)
+			} else if errors.Is(err, os.ErrNotExist) {
+				p.Skipped, err = true, nil
+			}
+			if err != nil {
+				return fmt.Errorf("backfill: %s: %w", path, err)
+			}
+			if err := record(journal, path); err != nil {
+				return err
+			}
+		default:
+			if p.BytesIn, p.BytesOut, err = compressFile(m, path); err != nil {
+				return fmt.Errorf("backfill: %s: %w", path, err)
+			}
+			// Record the intent first: once renamed, the original is compressed
+			// and must never be compressed again
+			if err := record(journal, pendingPrefix+path); err != nil {
+				return err
+			}
+			if err := replace(path); err != nil {
+				return fmt.Errorf("backfill: %s: %w", path, err)
+			}
+			if err := record(journal, path); err != nil {
+				return err
+			}
+		}
+
+		if c.progress != nil {
+			c.progress(p)
+		}
+	}
+	return nil
+}
+
+// record appends a line to the journal and syncs it, if there is a journal
+func record(journal *os.File, line string) error {
+	if journal == nil {
+		return nil
+	}
+	if _, err := fmt.Fprintln(journal, line); err != nil {
+		return fmt.Errorf("backfill: failed to update journal: %w", err)
+	}
+	if err := journal.Sync(); err != nil {
+		return fmt.Errorf("backfill: failed to sync journal: %w", err)
+	}
+	return nil
+}
+
+// loadJournal returns the sets of paths recorded as completed and as about to be renamed
+func loadJournal(path string) (completed, pending map[string]bool, err error) {
+	completed, pending = make(map[string]bool), make(map[string]bool)
+	if path == "" {
+		return completed, pending, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return completed, pending, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("backfill: failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if name, ok := strings.CutPrefix(line, pendingPrefix); ok {
+			pending[name] = true
+		} else if line != "" {
+			completed[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("backfill: failed to read journal: %w", err)
+	}
+	return completed, pending, nil
+}
+
+// replace renames the verified temporary file over path and syncs the
+// directory, so the rename itself survives a crash
+func replace(path string) error {
+	if err := os.Rename(path+TempSuffix, path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// compressFile compresses path into a temporary file and verifies it. The
+// caller swaps it in with replace.
+func compressFile(m middleware.Middleware, path string) (in, out int64, err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tmpPath := path + TempSuffix
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, info.Mode().Perm())
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// Compress while hashing the original data
+	original := sha256.New()
+	cw := m.Writer(tmp)
+	if in, err = io.Copy(cw, io.TeeReader(src, original)); err != nil {
+		return 0, 0, fmt.Errorf("failed to compress: %w", err)
+	}
+	if closer, ok := cw.(io.Closer); ok {
+		if err = closer.Close(); err != nil {
+			return 0, 0, fmt.Errorf("failed to compress: %w", err)
+		}
+	}
+	if err = tmp.Sync(); err != nil {
+		return 0, 0, err
+	}
+
+	// Verify the round trip from what actually landed on disk
+	if out, err = tmp.Seek(0, io.SeekCurrent); err != nil {
+		return 0, 0, err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, err
+	}
+	roundTrip := sha256.New()
+	dr := m.Reader(bufio.NewReader(tmp))
+	if _, err = io.Copy(roundTrip, dr); err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+	if closer, ok := dr.(io.Closer); ok {
+		closer.Close()
+	}
+	if !bytes.Equal(original.Sum(nil), roundTrip.Sum(nil)) {
+		return 0, 0, ErrVerificationFailed
+	}
+
+	if err = tmp.Close(); err != nil {
+		return 0, 0, err
+	}
+	return in, out, nil
+}
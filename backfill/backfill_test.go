@@ -0,0 +1,153 @@
+package backfill
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	compression "schneider.vip/hybridbuffer/middleware/compressionstdlib"
+)
+
+func writeFiles(t *testing.T, dir string, n int) ([]string, map[string][]byte) {
+	t.Helper()
+	var paths []string
+	contents := make(map[string][]byte)
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("spill-%d", i))
+		data := bytes.Repeat([]byte(fmt.Sprintf("spill file %d ", i)), 1000)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		paths = append(paths, path)
+		contents[path] = data
+	}
+	return paths, contents
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	paths, contents := writeFiles(t, dir, 3)
+	m := compression.New(compression.Gzip)
+
+	var progress []Progress
+	err := Run(context.Background(), m, paths, WithProgress(func(p Progress) {
+		progress = append(progress, p)
+	}))
+	if err != nil {
+		t.Fatalf("Failed to backfill: %v", err)
+	}
+	if len(progress) != 3 || progress[2].Done != 3 || progress[2].Total != 3 {
+		t.Fatalf("Unexpected progress reports: %+v", progress)
+	}
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", path, err)
+		}
+		got, err := io.ReadAll(m.Reader(f))
+		f.Close()
+		if err != nil {
+			t.Fatalf("Failed to decompress %s: %v", path, err)
+		}
+		if !bytes.Equal(got, contents[path]) {
+			t.Fatalf("Data mismatch for %s", path)
+		}
+		if _, err := os.Stat(path + TempSuffix); !os.IsNotExist(err) {
+			t.Fatalf("Expected temporary file for %s to be gone", path)
+		}
+	}
+}
+
+func TestRun_Resume(t *testing.T) {
+	dir := t.TempDir()
+	paths, _ := writeFiles(t, dir, 3)
+	journal := filepath.Join(dir, "journal")
+	m := compression.New(compression.Gzip)
+
+	// First run is interrupted after the first file
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Run(ctx, m, paths, WithJournal(journal), WithProgress(func(Progress) { cancel() }))
+	if err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	var skipped int
+	err = Run(context.Background(), m, paths, WithJournal(journal), WithProgress(func(p Progress) {
+		if p.Skipped {
+			skipped++
+		}
+	}))
+	if err != nil {
+		t.Fatalf("Failed to resume: %v", err)
+	}
+	if skipped != 1 {
+		t.Fatalf("Expected 1 skipped file, got %d", skipped)
+	}
+}
+
+func TestRun_ResumePendingRename(t *testing.T) {
+	dir := t.TempDir()
+	paths, contents := writeFiles(t, dir, 2)
+	journal := filepath.Join(dir, "journal")
+	m := compression.New(compression.Gzip)
+
+	// paths[0] crashed after the rename, paths[1] before it
+	if err := Run(context.Background(), m, paths[:1]); err != nil {
+		t.Fatalf("Failed to backfill: %v", err)
+	}
+	tmp, err := os.Create(paths[1] + TempSuffix)
+	if err != nil {
+		t.Fatalf("Failed to create temporary file: %v", err)
+	}
+	w := m.Writer(tmp).(io.WriteCloser)
+	w.Write(contents[paths[1]])
+	w.Close()
+	tmp.Close()
+	lines := pendingPrefix + paths[0] + "\n" + pendingPrefix + paths[1] + "\n"
+	if err := os.WriteFile(journal, []byte(lines), 0o600); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	var skipped []bool
+	err = Run(context.Background(), m, paths, WithJournal(journal), WithProgress(func(p Progress) {
+		skipped = append(skipped, p.Skipped)
+	}))
+	if err != nil {
+		t.Fatalf("Failed to resume: %v", err)
+	}
+	if len(skipped) != 2 || !skipped[0] || skipped[1] {
+		t.Fatalf("Expected only the renamed file to be skipped, got %v", skipped)
+	}
+
+	// Both files are compressed exactly once
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", path, err)
+		}
+		got, err := io.ReadAll(m.Reader(f))
+		f.Close()
+		if err != nil {
+			t.Fatalf("Failed to decompress %s: %v", path, err)
+		}
+		if !bytes.Equal(got, contents[path]) {
+			t.Fatalf("Data mismatch for %s", path)
+		}
+		if _, err := os.Stat(path + TempSuffix); !os.IsNotExist(err) {
+			t.Fatalf("Expected temporary file for %s to be gone", path)
+		}
+	}
+
+	completed, pending, err := loadJournal(journal)
+	if err != nil {
+		t.Fatalf("Failed to load journal: %v", err)
+	}
+	if !completed[paths[0]] || !completed[paths[1]] || len(pending) != 2 {
+		t.Fatalf("Expected both files completed, got %v", completed)
+	}
+}
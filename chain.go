@@ -0,0 +1,89 @@
+package compressionstdlib
+
+import (
+	"io"
+
+	"schneider.vip/hybridbuffer/middleware"
+)
+
+// Chain applies several middlewares as one, for example a pre-filter followed
+// by a compressor. Writers apply them in the given order; readers undo them in
+// reverse order.
+type Chain struct {
+	mws []middleware.Middleware
+}
+
+// Ensure Chain implements middleware.Middleware interface
+var _ middleware.Middleware = (*Chain)(nil)
+
+// NewChain creates a middleware applying mws in order, so that
+// NewChain(New(Flate), New(Gzip)) writes gzip compressed raw deflate data
+func NewChain(mws ...middleware.Middleware) *Chain {
+	return &Chain{mws: mws}
+}
+
+// Writer wraps w with all middlewares. Close finalizes every layer, innermost
+// data transform first.
+func (c *Chain) Writer(w io.Writer) io.Writer {
+	layers := make([]io.Writer, len(c.mws))
+	for i := len(c.mws) - 1; i >= 0; i-- {
+		w = c.mws[i].Writer(w)
+		layers[i] = w
+	}
+	return &chainWriter{Writer: w, layers: layers}
+}
+
+// Reader wraps r with all middlewares in reverse order
+func (c *Chain) Reader(r io.Reader) io.Reader {
+	layers := make([]io.Reader, 0, len(c.mws))
+	for i := len(c.mws) - 1; i >= 0; i-- {
+		r = c.mws[i].Reader(r)
+		layers = append(layers, r)
+	}
+	return &chainReader{Reader: r, layers: layers}
+}
+
+// chainWriter writes into the first layer and finalizes all layers on Close
+type chainWriter struct {
+	io.Writer
+	layers []io.Writer
+}
+
+func (w *chainWriter) Flush() error {
+	for _, layer := range w.layers {
+		if err := flush(layer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *chainWriter) Close() error {
+	var firstErr error
+	for _, layer := range w.layers {
+		if closer, ok := layer.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// chainReader reads from the last layer and closes all layers on Close
+type chainReader struct {
+	io.Reader
+	layers []io.Reader
+}
+
+func (r *chainReader) Close() error {
+	var firstErr error
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		if closer, ok := r.layers[i].(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
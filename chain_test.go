@@ -0,0 +1,61 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	data := bytes.Repeat([]byte("chained transforms "), 500)
+
+	chain := NewChain(New(Flate), New(Gzip))
+	compressed := compressWith(t, chain, data)
+
+	// The outermost layer is the last middleware of the chain
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("Expected gzip outer layer: %v", err)
+	}
+	inner, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to read gzip layer: %v", err)
+	}
+	got, err := io.ReadAll(New(Flate).Reader(bytes.NewReader(inner)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Expected raw deflate inner layer: %v", err)
+	}
+
+	r := chain.Reader(bytes.NewReader(compressed))
+	got, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read chain: %v", err)
+	}
+	if err := r.(io.Closer).Close(); err != nil {
+		t.Fatalf("Failed to close chain reader: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestChainFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewChain(New(Zlib), New(Gzip)).Writer(&buf)
+	if _, err := w.Write([]byte("flushed through")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if err := w.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	got := make([]byte, 15)
+	r := NewChain(New(Zlib), New(Gzip)).Reader(bytes.NewReader(buf.Bytes()))
+	if _, err := io.ReadFull(r, got); err != nil {
+		t.Fatalf("Failed to read flushed data: %v", err)
+	}
+	if string(got) != "flushed through" {
+		t.Fatalf("Expected %q, got %q", "flushed through", got)
+	}
+}
@@ -0,0 +1,60 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// ChunkIterator pulls data from an input reader and yields its compressed form
+// in chunks of bounded size. It suits chunk-oriented sinks such as multipart
+// uploads, which otherwise need pipes and goroutines around io.Writer.
+type ChunkIterator struct {
+	r    io.Reader
+	size int
+	out  bytes.Buffer
+	cw   io.Writer
+	in   []byte
+	eof  bool
+}
+
+// NewChunkIterator creates an iterator compressing r into chunks of chunkSize
+// bytes; only the final chunk may be smaller
+func (m *Middleware) NewChunkIterator(r io.Reader, chunkSize int) *ChunkIterator {
+	if chunkSize <= 0 {
+		chunkSize = 32 * 1024
+	}
+	it := &ChunkIterator{r: r, size: chunkSize, in: make([]byte, 32*1024)}
+	it.cw = m.Writer(&it.out)
+	return it
+}
+
+// Next returns the next compressed chunk, or io.EOF once the input is exhausted
+// and all compressed data has been returned. The chunk is owned by the caller.
+func (it *ChunkIterator) Next() ([]byte, error) {
+	for it.out.Len() < it.size && !it.eof {
+		n, err := it.r.Read(it.in)
+		if n > 0 {
+			if _, werr := it.cw.Write(it.in[:n]); werr != nil {
+				return nil, fmt.Errorf("failed to compress chunk: %w", werr)
+			}
+		}
+		if err == io.EOF {
+			it.eof = true
+			if closer, ok := it.cw.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					return nil, fmt.Errorf("failed to compress chunk: %w", err)
+				}
+			}
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if it.out.Len() == 0 {
+		return nil, io.EOF
+	}
+	chunk := make([]byte, min(it.size, it.out.Len()))
+	it.out.Read(chunk)
+	return chunk, nil
+}
@@ -0,0 +1,62 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestChunkIterator(t *testing.T) {
+	data := make([]byte, 300000)
+	rand.New(rand.NewSource(1)).Read(data[:100000])
+
+	m := New(Gzip)
+	it := m.NewChunkIterator(bytes.NewReader(data), 10000)
+
+	var compressed []byte
+	var chunks [][]byte
+	for {
+		chunk, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to get chunk: %v", err)
+		}
+		chunks = append(chunks, chunk)
+		compressed = append(compressed, chunk...)
+	}
+
+	for i, chunk := range chunks[:len(chunks)-1] {
+		if len(chunk) != 10000 {
+			t.Fatalf("Chunk %d: Expected 10000 bytes, got %d", i, len(chunk))
+		}
+	}
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to read chunks: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Chunked data mismatch")
+	}
+}
+
+func TestChunkIterator_Empty(t *testing.T) {
+	m := New(Zlib)
+	it := m.NewChunkIterator(bytes.NewReader(nil), 1024)
+
+	chunk, err := it.Next()
+	if err != nil {
+		t.Fatalf("Expected the empty stream as a chunk, got %v", err)
+	}
+	if _, err := it.Next(); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(chunk)))
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Expected empty data, got %d bytes and %v", len(got), err)
+	}
+}
@@ -0,0 +1,235 @@
+package compressionstdlib
+
+import (
+	"fmt"
+	"io"
+
+	"schneider.vip/hybridbuffer/middleware"
+)
+
+// CloseAware adapts a middleware so its streams are returned as io.WriteCloser
+// and io.ReadCloser, sparing hosts the io.Closer type assertion that is easy to
+// forget and leaves compressed streams truncated. Closing a stream finalizes the
+// compressed data; the wrapped writer or reader is only closed as well if the
+// middleware uses WithCloseUnderlying.
+type CloseAware struct {
+	mw middleware.Middleware
+}
+
+// NewCloseAware creates a close-aware adapter for mw
+func NewCloseAware(mw middleware.Middleware) *CloseAware {
+	return &CloseAware{mw: mw}
+}
+
+// Writer wraps w with compression. The stream is complete only after Close.
+func (c *CloseAware) Writer(w io.Writer) io.WriteCloser {
+	return asWriteCloser(c.mw.Writer(w))
+}
+
+// Reader wraps r with decompression. Close releases the decompressor.
+func (c *CloseAware) Reader(r io.Reader) io.ReadCloser {
+	return asReadCloser(c.mw.Reader(r))
+}
+
+// Flusher is implemented by the writers returned by Writer, NewWriter and
+// WriterCloser. Flush writes all data compressed so far to the wrapped writer,
+// so it becomes readable downstream while the stream stays open. LZW streams
+// cannot be flushed; their data becomes readable on Close.
+type Flusher interface {
+	Flush() error
+}
+
+// WithFlushEvery flushes writers automatically once n uncompressed bytes have
+// been written since the last flush, bounding the latency for consumers tailing
+// the compressed data. Each flush costs a few bytes of output and some ratio.
+// Values of zero or below disable automatic flushing.
+func WithFlushEvery(n int) Option {
+	return func(m *Middleware) {
+		m.flushEvery = max(n, 0)
+	}
+}
+
+// WriterCloser is like Writer, but returns an io.WriteCloser, so the stream
+// can be finalized without an io.Closer type assertion. The compressed data is
+// complete only after Close.
+func (m *Middleware) WriterCloser(w io.Writer) io.WriteCloser {
+	return asWriteCloser(m.Writer(w))
+}
+
+// ReadCloser is like Reader, but returns an io.ReadCloser. Closing it releases
+// the decompressor and its buffers promptly, even before the stream was read
+// to the end.
+func (m *Middleware) ReadCloser(r io.Reader) io.ReadCloser {
+	return asReadCloser(m.Reader(r))
+}
+
+// NewWriter is like Writer, but reports configuration errors, such as an
+// algorithm missing from the build or an unregistered dictionary name, instead
+// of panicking
+func (m *Middleware) NewWriter(w io.Writer) (wc io.WriteCloser, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			wc, err = nil, m.report("open", panicError(r))
+		}
+	}()
+	return asWriteCloser(m.Writer(w)), nil
+}
+
+// NewReader is like Reader, but reports errors detected while opening the
+// stream, such as a malformed header, instead of deferring them to Read
+func (m *Middleware) NewReader(r io.Reader) (io.ReadCloser, error) {
+	release := m.acquire()
+	dr := m.reader(r)
+	if er, ok := dr.(*errReader); ok {
+		if release != nil {
+			release()
+		}
+		return nil, m.report("open", er.err)
+	}
+	dr = &deferredReader{r: dr, src: r, m: m, underlying: m.underlying(r)}
+	if release != nil {
+		dr = &gatedReader{Reader: dr, release: release}
+	}
+	return asReadCloser(dr), nil
+}
+
+// WithCloseUnderlying makes closing a compressing writer or decompressing
+// reader also close the wrapped writer or reader, if it implements io.Closer
+func WithCloseUnderlying() Option {
+	return func(m *Middleware) {
+		m.closeUnderlying = true
+	}
+}
+
+// underlying returns the closer of a wrapped stream that is closed along with it
+func (m *Middleware) underlying(stream any) io.Closer {
+	if !m.closeUnderlying {
+		return nil
+	}
+	closer, _ := stream.(io.Closer)
+	return closer
+}
+
+// closeUnderlying closes c after the wrapping stream was closed with err,
+// returning the first error
+func closeUnderlying(err error, c io.Closer) error {
+	if c == nil {
+		return err
+	}
+	if cerr := c.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// panicError converts a recovered panic value into an error
+func panicError(r any) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", r)
+}
+
+// asWriteCloser returns w as an io.WriteCloser, keeping Flush available
+func asWriteCloser(w io.Writer) io.WriteCloser {
+	if wc, ok := w.(interface {
+		io.WriteCloser
+		Flush() error
+	}); ok {
+		return wc
+	}
+	return &writeCloser{Writer: w}
+}
+
+// asReadCloser returns r as an io.ReadCloser
+func asReadCloser(r io.Reader) io.ReadCloser {
+	if rc, ok := r.(io.ReadCloser); ok {
+		return rc
+	}
+	return io.NopCloser(r)
+}
+
+// onceWriter makes Close idempotent, since cleanup paths may close a stream
+// twice, and rejects writes after Close
+type onceWriter struct {
+	io.Writer
+	m          *Middleware
+	out        *countingWriter // compressed output
+	dst        io.Writer       // destination of the compressor, writing to out
+	n          int64           // uncompressed bytes accepted
+	flushed    int64           // value of n at the last flush
+	closed     bool
+	underlying io.Closer
+	size       *sizePatch // records the original size on Close, if enabled
+}
+
+// fail wraps err with the current stream offsets and reports it
+func (w *onceWriter) fail(op string, err error) error {
+	return w.m.report(op, w.m.streamError(op, err, w.out.n, w.n))
+}
+
+func (w *onceWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, w.fail("write", ErrWriterClosed)
+	}
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+	if err != nil {
+		return n, w.fail("write", err)
+	}
+	if w.m.flushEvery > 0 && w.n-w.flushed >= int64(w.m.flushEvery) {
+		return n, w.Flush()
+	}
+	return n, nil
+}
+
+// Flush flushes the compressor and then the wrapped writer, so buffered
+// destinations such as a bufio.Writer pass the data on as well
+func (w *onceWriter) Flush() error {
+	if w.closed {
+		return w.fail("flush", ErrWriterClosed)
+	}
+	w.flushed = w.n
+	err := flush(w.Writer)
+	if err == nil {
+		err = flush(w.dst)
+	}
+	return w.fail("flush", err)
+}
+
+func (w *onceWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	var err error
+	if closer, ok := w.Writer.(io.Closer); ok {
+		err = closer.Close()
+	}
+	if err == nil && w.m.sizeTrailer() {
+		err = writeSizeTrailer(w.dst, w.n)
+	}
+	if err == nil {
+		err = drain(w.dst)
+	}
+	if err == nil && w.size != nil {
+		err = w.size.apply(w.n)
+	}
+	return w.fail("close", closeUnderlying(err, w.underlying))
+}
+
+// writeCloser adds Flush and Close to writers that may lack them
+type writeCloser struct {
+	io.Writer
+}
+
+func (w *writeCloser) Flush() error {
+	return flush(w.Writer)
+}
+
+func (w *writeCloser) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
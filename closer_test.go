@@ -0,0 +1,311 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCloseAware(t *testing.T) {
+	data := bytes.Repeat([]byte("close aware "), 100)
+
+	for _, m := range []*Middleware{
+		New(Gzip),
+		New(Zlib, WithMaxConcurrentStreams(1)),
+		NewLazy(WithOmitEmptyStream()),
+	} {
+		c := NewCloseAware(m)
+
+		var buf bytes.Buffer
+		w := c.Writer(&buf)
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Failed to close writer: %v", err)
+		}
+
+		r := c.Reader(&buf)
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatalf("Failed to close reader: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatal("Data mismatch")
+		}
+	}
+}
+
+func TestFlushDestination(t *testing.T) {
+	for _, m := range []*Middleware{New(Gzip), New(Zlib), New(Flate), NewLazy(), New(Gzip, WithHeaderCRC())} {
+		var buf bytes.Buffer
+		dst := bufio.NewWriter(&buf)
+		w := m.Writer(dst)
+		if _, err := w.Write([]byte("tail me")); err != nil {
+			t.Fatalf("%v: Failed to write: %v", m.algorithm, err)
+		}
+		if err := w.(Flusher).Flush(); err != nil {
+			t.Fatalf("%v: Failed to flush: %v", m.algorithm, err)
+		}
+
+		// The flushed data passed through the buffered destination
+		got := make([]byte, 7)
+		if _, err := io.ReadFull(m.Reader(bytes.NewReader(buf.Bytes())), got); err != nil {
+			t.Fatalf("%v: Failed to read flushed data: %v", m.algorithm, err)
+		}
+		if string(got) != "tail me" {
+			t.Fatalf("%v: Expected %q, got %q", m.algorithm, "tail me", got)
+		}
+	}
+}
+
+func TestFlushEvery(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(Gzip, WithFlushEvery(100)).Writer(&buf)
+
+	if _, err := w.Write(bytes.Repeat([]byte("a"), 60)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if _, err := io.ReadFull(New(Gzip).Reader(bytes.NewReader(buf.Bytes())), make([]byte, 1)); err == nil {
+		t.Fatal("Expected no readable data before the threshold")
+	}
+	if _, err := w.Write(bytes.Repeat([]byte("b"), 60)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	got := make([]byte, 120)
+	if _, err := io.ReadFull(New(Gzip).Reader(bytes.NewReader(buf.Bytes())), got); err != nil {
+		t.Fatalf("Failed to read auto-flushed data: %v", err)
+	}
+	if !bytes.Equal(got[60:], bytes.Repeat([]byte("b"), 60)) {
+		t.Fatalf("Unexpected data %q", got)
+	}
+}
+
+func TestWriterCloser(t *testing.T) {
+	data := bytes.Repeat([]byte("writer closer "), 1000)
+
+	for _, m := range []*Middleware{New(Gzip), New(Flate), New(Gzip, WithParallel(2))} {
+		var buf bytes.Buffer
+		w := m.WriterCloser(&buf)
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("%v: Failed to write: %v", m.algorithm, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%v: Failed to close writer: %v", m.algorithm, err)
+		}
+
+		got, err := io.ReadAll(m.Reader(&buf))
+		if err != nil {
+			t.Fatalf("%v: Failed to read: %v", m.algorithm, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: Data mismatch", m.algorithm)
+		}
+	}
+}
+
+func TestReadCloser(t *testing.T) {
+	m := New(Gzip, WithMaxConcurrentStreams(1))
+	compressed := compressWith(t, New(Gzip), bytes.Repeat([]byte("read closer "), 1000))
+
+	r := m.ReadCloser(bytes.NewReader(compressed))
+	if _, err := io.ReadFull(r, make([]byte, 10)); err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Failed to close reader: %v", err)
+	}
+
+	// Closing a partially read stream releases its slot
+	done := make(chan error, 1)
+	go func() {
+		r := m.ReadCloser(bytes.NewReader(compressed))
+		_, err := io.ReadAll(r)
+		r.Close()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Closed reader did not release its slot")
+	}
+}
+
+func TestCloseAwareFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCloseAware(New(Gzip)).Writer(&buf)
+	if _, err := w.Write([]byte("flushed")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if err := w.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// Flushed data is readable before the stream is closed
+	got := make([]byte, 7)
+	if _, err := io.ReadFull(New(Gzip).Reader(bytes.NewReader(buf.Bytes())), got); err != nil {
+		t.Fatalf("Failed to read flushed data: %v", err)
+	}
+	if string(got) != "flushed" {
+		t.Fatalf("Expected %q, got %q", "flushed", got)
+	}
+}
+
+func TestNewWriterReader(t *testing.T) {
+	data := bytes.Repeat([]byte("error returning constructors "), 100)
+	m := New(Gzip, WithMaxConcurrentStreams(1))
+
+	var buf bytes.Buffer
+	w, err := m.NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+
+	r, err := m.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	r.Close()
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestNewWriterErrors(t *testing.T) {
+	if _, err := New(Algorithm(99)).NewWriter(&bytes.Buffer{}); err == nil {
+		t.Fatal("Expected error for unsupported algorithm")
+	}
+	_, err := New(Zlib, WithDictionaryName("never-registered")).NewWriter(&bytes.Buffer{})
+	if !errors.Is(err, ErrUnknownDictionary) {
+		t.Fatalf("Expected ErrUnknownDictionary, got %v", err)
+	}
+}
+
+func TestNewReaderMalformed(t *testing.T) {
+	m := New(Gzip, WithMaxConcurrentStreams(1))
+	if _, err := m.NewReader(bytes.NewReader([]byte("not gzip"))); err == nil {
+		t.Fatal("Expected error for malformed stream")
+	}
+	// The failed reader must not hold the only stream slot
+	if _, err := m.NewReader(bytes.NewReader(compressWith(t, New(Gzip), []byte("ok")))); err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+}
+
+func TestCloseIdempotent(t *testing.T) {
+	data := bytes.Repeat([]byte("closed twice "), 100)
+
+	for _, m := range []*Middleware{
+		New(Gzip),
+		New(Zlib, WithMaxConcurrentStreams(1)),
+		New(Flate),
+		New(LZW),
+		New(Zlib, WithPaddingBuckets(4096)),
+		New(Gzip, WithArmor(ArmorBase64, 76)),
+		NewLazy(),
+	} {
+		once := compressWith(t, m, data)
+
+		var buf bytes.Buffer
+		w := m.Writer(&buf).(io.WriteCloser)
+		w.Write(data)
+		if err := w.Close(); err != nil {
+			t.Fatalf("%v: First close failed: %v", m.algorithm, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%v: Second close failed: %v", m.algorithm, err)
+		}
+		if !bytes.Equal(buf.Bytes(), once) {
+			t.Fatalf("%v: Second close changed the output", m.algorithm)
+		}
+	}
+}
+
+// closeCounter counts Close calls on a wrapped stream
+type closeCounter struct {
+	bytes.Buffer
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestCloseUnderlying(t *testing.T) {
+	for _, m := range []*Middleware{
+		New(Gzip, WithCloseUnderlying()),
+		New(Zlib, WithCloseUnderlying(), WithMaxConcurrentStreams(1)),
+	} {
+		dst := &closeCounter{}
+		w := m.Writer(dst).(io.WriteCloser)
+		w.Write([]byte("propagate close"))
+		w.Close()
+		w.Close()
+		if dst.closed != 1 {
+			t.Fatalf("%v: Expected writer destination closed once, got %d", m.algorithm, dst.closed)
+		}
+
+		src := &closeCounter{}
+		src.Write(dst.Bytes())
+		r := m.Reader(src).(io.ReadCloser)
+		if _, err := io.ReadAll(r); err != nil {
+			t.Fatalf("%v: Failed to read: %v", m.algorithm, err)
+		}
+		r.Close()
+		if src.closed != 1 {
+			t.Fatalf("%v: Expected reader source closed once, got %d", m.algorithm, src.closed)
+		}
+
+		src = &closeCounter{}
+		src.Write(dst.Bytes())
+		rc, err := m.NewReader(src)
+		if err != nil {
+			t.Fatalf("%v: Failed to create reader: %v", m.algorithm, err)
+		}
+		rc.Close()
+		if src.closed != 1 {
+			t.Fatalf("%v: Expected NewReader source closed once, got %d", m.algorithm, src.closed)
+		}
+	}
+
+	dst := &closeCounter{}
+	w := New(Gzip).Writer(dst).(io.WriteCloser)
+	w.Close()
+	if dst.closed != 0 {
+		t.Fatal("Expected destination to stay open by default")
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	for _, m := range []*Middleware{New(Gzip), New(Zlib, WithParallel(2)), New(RLE), NewLazy()} {
+		w := m.Writer(&bytes.Buffer{}).(io.WriteCloser)
+		w.Write([]byte("before close"))
+		w.Close()
+
+		if _, err := w.Write([]byte("after close")); !errors.Is(err, ErrWriterClosed) {
+			t.Fatalf("%v: Expected ErrWriterClosed from Write, got %v", m.algorithm, err)
+		}
+		if err := flush(w); !errors.Is(err, ErrWriterClosed) {
+			t.Fatalf("%v: Expected ErrWriterClosed from Flush, got %v", m.algorithm, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package compressionstdlib
+
+import (
+	"fmt"
+	"io"
+)
+
+// codec is a compression backend outside the standard library. Backends live
+// in files behind a build tag and register themselves on init, so the default
+// build compiles no external compression code.
+type codec struct {
+	newWriter func(w io.Writer, level int) (io.WriteCloser, error)
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// codecs holds the backends compiled into this build
+var codecs = map[Algorithm]codec{}
+
+// codecTags names the build tag enabling each optional backend
+var codecTags = map[Algorithm]string{
+	Zstd:   "zstd",
+	LZ4:    "lz4",
+	Brotli: "brotli",
+	Snappy: "snappy",
+	S2:     "s2",
+	XZ:     "xz",
+}
+
+func registerCodec(algorithm Algorithm, c codec) {
+	codecs[algorithm] = c
+}
+
+// unsupportedAlgorithm describes why no backend is available for the algorithm
+func unsupportedAlgorithm(algorithm Algorithm) error {
+	if tag, ok := codecTags[algorithm]; ok {
+		return fmt.Errorf("%w: build with -tags %s", ErrUnsupportedAlgorithm, tag)
+	}
+	return fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, algorithm)
+}
+
+// codecWriter creates the compressing writer of an optional backend
+func (m *Middleware) codecWriter(w io.Writer) io.Writer {
+	c, ok := codecs[m.algorithm]
+	if !ok {
+		// Like the other configuration errors, reported by NewWriter
+		panic(unsupportedAlgorithm(m.algorithm))
+	}
+	cw, err := c.newWriter(w, m.level)
+	if err != nil {
+		return &errWriter{fmt.Errorf("failed to create compressor: %w", err)}
+	}
+	return cw
+}
+
+// codecReader creates the decompressing reader of an optional backend
+func codecReader(algorithm Algorithm, r io.Reader) (io.Reader, error) {
+	c, ok := codecs[algorithm]
+	if !ok {
+		return nil, unsupportedAlgorithm(algorithm)
+	}
+	cr, err := c.newReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reader: %w", err)
+	}
+	return cr, nil
+}
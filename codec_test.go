@@ -0,0 +1,67 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	if len(codecs) == 0 {
+		t.Skip("no optional backends compiled in; run with -tags zstd,lz4,brotli,snappy,s2,xz")
+	}
+	data := bytes.Repeat([]byte("optional backend "), 1000)
+
+	for algorithm := range codecs {
+		for _, level := range []int{1, 6, 9} {
+			m := New(algorithm, WithLevel(level))
+			got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+			if err != nil {
+				t.Fatalf("Algorithm %d level %d: read failed: %v", algorithm, level, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("Algorithm %d level %d: data mismatch", algorithm, level)
+			}
+		}
+	}
+}
+
+func TestCodecNotCompiledIn(t *testing.T) {
+	for algorithm, tag := range codecTags {
+		if _, ok := codecs[algorithm]; ok {
+			continue
+		}
+		_, err := io.ReadAll(New(algorithm).Reader(bytes.NewReader([]byte("data"))))
+		if err == nil || !strings.Contains(err.Error(), "-tags "+tag) {
+			t.Fatalf("Expected error naming build tag %q, got %v", tag, err)
+		}
+
+		if _, err := New(algorithm).NewWriter(&bytes.Buffer{}); !errors.Is(err, ErrUnsupportedAlgorithm) || !strings.Contains(err.Error(), "-tags "+tag) {
+			t.Fatalf("Expected error naming build tag %q, got %v", tag, err)
+		}
+	}
+}
+
+func TestCodecPadding(t *testing.T) {
+	if len(codecs) == 0 {
+		t.Skip("no optional backends compiled in; run with -tags zstd,lz4,brotli,snappy,s2,xz")
+	}
+
+	// The codecs reject trailing data, so their streams are never padded
+	data := bytes.Repeat([]byte("optional backend "), 1000)
+	for algorithm := range codecs {
+		if _, err := NewE(algorithm, WithPaddingBuckets(2048)); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("Algorithm %v: Expected ErrInvalidOption, got %v", algorithm, err)
+		}
+		m := New(algorithm, WithPaddingBuckets(2048))
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+		if err != nil {
+			t.Fatalf("Algorithm %v: read failed: %v", algorithm, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Algorithm %v: data mismatch", algorithm)
+		}
+	}
+}
@@ -2,10 +2,16 @@
 package compressionstdlib
 
 import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
 	"compress/gzip"
+	"compress/lzw"
 	"compress/zlib"
 	"fmt"
 	"io"
+	"time"
 
 	"schneider.vip/hybridbuffer/middleware"
 )
@@ -18,12 +24,149 @@ const (
 	Gzip Algorithm = iota
 	// Zlib compression using compress/zlib
 	Zlib
+	// Flate is raw DEFLATE (RFC 1951) using compress/flate, without headers or checksums
+	Flate
+	// Bzip2 decompression using compress/bzip2. It is read-only, as the
+	// standard library has no bzip2 encoder.
+	Bzip2
+	// Zstd compression using github.com/klauspost/compress/zstd. It requires
+	// building with -tags zstd.
+	Zstd
+	// LZ4 compression using github.com/pierrec/lz4/v4, trading ratio for
+	// speed. It requires building with -tags lz4.
+	LZ4
+	// Brotli compression using github.com/andybalholm/brotli, with levels
+	// 0-11. It requires building with -tags brotli.
+	Brotli
+	// Snappy compression using the framing format of github.com/golang/snappy.
+	// Levels are ignored. It requires building with -tags snappy.
+	Snappy
+	// S2 compression using github.com/klauspost/compress/s2, a Snappy
+	// compatible format with a better ratio and a concurrent encoder. It
+	// requires building with -tags s2.
+	S2
+	// XZ (LZMA2) compression using github.com/ulikunitz/xz, for the best ratio
+	// at a high CPU cost. It requires building with -tags xz.
+	XZ
+	// None passes data through unchanged, keeping the middleware in the chain
+	// without compressing
+	None
+	// Auto selects gzip, raw deflate or no compression per stream by measuring
+	// how well the first bytes written compress. The choice is recorded in a
+	// stream header, so the Reader needs no configuration.
+	Auto
+	// RLE is PackBits run-length encoding, a very cheap transform for highly
+	// repetitive data. Levels are ignored.
+	RLE
+	// LZW compression using compress/lzw, for interoperability with formats
+	// such as GIF, TIFF and PDF. Levels are ignored; see WithLZWOrder and
+	// WithLZWLitWidth.
+	LZW
 )
 
+// Compression levels for WithLevel, matching compress/flate
+const (
+	NoCompression      = flate.NoCompression
+	BestSpeed          = flate.BestSpeed
+	BestCompression    = flate.BestCompression
+	DefaultCompression = flate.DefaultCompression
+	// HuffmanOnly entropy codes the data without searching for matches; it
+	// applies to deflate based algorithms only
+	HuffmanOnly = flate.HuffmanOnly
+)
+
+// defaultLevel is the level used unless WithLevel selects another
+const defaultLevel = 6
+
+// levelRange returns the valid compression levels of the algorithm
+func (a Algorithm) levelRange() (lo, hi int) {
+	switch {
+	case a == Brotli:
+		return 0, 11
+	case a.deflate():
+		return NoCompression, 9
+	}
+	return 1, 9
+}
+
+// deflate reports whether the algorithm compresses with deflate
+func (a Algorithm) deflate() bool {
+	return a == Gzip || a == Zlib || a == Flate || a == Auto
+}
+
+// checksummed reports whether the algorithm frames deflate data with checksums
+// the trusted pipeline can skip
+func (a Algorithm) checksummed() bool {
+	return a == Gzip || a == Zlib
+}
+
 // Middleware implements compression/decompression
 type Middleware struct {
-	algorithm Algorithm
-	level     int
+	algorithm    Algorithm
+	level        int
+	headerLimits *headerLimits
+	maxNesting   int
+	streams      chan struct{}
+
+	paddingBuckets      []int
+	verifyBeforeRelease bool
+	trusted             bool
+
+	seedSize  int
+	seeds     *segmentSeeds
+	dictStore DictionaryStore
+
+	// selector picks the algorithm and level per stream from its first bytes
+	selector   func(m *Middleware, sample []byte) (Algorithm, int)
+	autoLevel  bool
+	sampleSize int
+	minRatio   float64
+	minSize    int
+	backend    Backend
+
+	deltaFilter  bool
+	shuffleWidth int
+
+	dictionary []byte
+	dictName   string
+
+	streamHeader   bool
+	seekableFormat bool
+	parallel       int
+
+	storedPassthrough bool
+
+	maxDecompressed int64
+	maxCompressed   int64
+	strictTrailer   bool
+	skipChecksum    bool
+	onSkip          func(SkippedRange)
+	closeUnderlying bool
+	onError         func(op string, err error)
+	retry           *RetryPolicy
+	stallTimeout    time.Duration
+	onNested        func(format string) error
+	flushEvery      int
+	deterministic   bool
+	originalSize    bool
+	writeBufferSize int
+	readBufferSize  int
+
+	levelErr    error // set when WithLevel was given an invalid level
+	strictLevel bool
+
+	lzwOrder    lzw.Order
+	lzwLitWidth int
+
+	compressedSize int64
+	readProgress   func(Progress)
+
+	armor      Armor
+	armorWidth int
+
+	singleStream bool
+	omitEmpty    bool
+	headerCRC    bool
 }
 
 // Ensure Middleware implements middleware.Middleware interface
@@ -32,20 +175,43 @@ var _ middleware.Middleware = (*Middleware)(nil)
 // Option configures compression middleware
 type Option func(*Middleware)
 
-// WithLevel sets the compression level (1-9, where 9 is best compression)
+// WithLevel sets the compression level (1-9, where 9 is best compression;
+// 0-11 for Brotli), such as BestSpeed or BestCompression. DefaultCompression
+// selects the default level 6. Deflate based algorithms also accept
+// NoCompression, which stores the data in deflate framing with checksums but
+// without compressing it, and HuffmanOnly, which entropy codes the data
+// without searching for matches.
+// Levels outside the algorithm's range are ignored, unless WithStrictLevel is
+// used; NewE always rejects them.
 func WithLevel(level int) Option {
 	return func(m *Middleware) {
-		if level >= 1 && level <= 9 {
+		if level == DefaultCompression {
+			level = defaultLevel
+		}
+		lo, hi := m.algorithm.levelRange()
+		if level >= lo && level <= hi || level == HuffmanOnly && m.algorithm.deflate() {
 			m.level = level
+			m.levelErr = nil
+		} else {
+			m.levelErr = fmt.Errorf("%w: %d is outside %d-%d for %v", ErrInvalidLevel, level, lo, hi, m.algorithm)
 		}
 	}
 }
 
+// WithStrictLevel makes an invalid WithLevel fail loudly instead of falling
+// back to the default level: Writer panics and NewWriter returns an error
+// matching ErrInvalidLevel.
+func WithStrictLevel() Option {
+	return func(m *Middleware) {
+		m.strictLevel = true
+	}
+}
+
 // New creates a new compression middleware with the given algorithm
 func New(algorithm Algorithm, opts ...Option) *Middleware {
 	m := &Middleware{
 		algorithm: algorithm,
-		level:     6, // Default compression level
+		level:     defaultLevel,
 	}
 
 	// Apply options
@@ -53,46 +219,358 @@ func New(algorithm Algorithm, opts ...Option) *Middleware {
 		opt(m)
 	}
 
+	if algorithm == Auto {
+		m.selector = (*Middleware).measureSample
+	}
+
 	return m
 }
 
-// Writer wraps an io.Writer with compression
+// NewGzip creates a gzip compression middleware; it is shorthand for New(Gzip, opts...)
+func NewGzip(opts ...Option) *Middleware {
+	return New(Gzip, opts...)
+}
+
+// NewZlib creates a zlib compression middleware; it is shorthand for New(Zlib, opts...)
+func NewZlib(opts ...Option) *Middleware {
+	return New(Zlib, opts...)
+}
+
+// Writer wraps an io.Writer with compression. The returned writer implements
+// io.Closer and Flusher. Closing it more than once is safe; later calls return nil.
 func (m *Middleware) Writer(w io.Writer) io.Writer {
+	if m.strictLevel && m.levelErr != nil {
+		panic(m.levelErr)
+	}
+	release := m.acquire()
+	out := &countingWriter{w: m.watchWriter(w)}
+	dst := m.buffering(m.retrying(out))
+	if release == nil {
+		return &onceWriter{Writer: m.writer(dst), m: m, out: out, dst: dst, underlying: m.underlying(w), size: m.newSizePatch(w)}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			release()
+			panic(r)
+		}
+	}()
+	return &onceWriter{Writer: &gatedWriter{Writer: m.writer(dst), release: release}, m: m, out: out, dst: dst, underlying: m.underlying(w), size: m.newSizePatch(w)}
+}
+
+// writer creates the compressor and layers the configured stream options around it
+func (m *Middleware) writer(w io.Writer) io.Writer {
+	if m.onNested != nil {
+		d := *m
+		d.onNested = nil
+		return &nestedCheckWriter{Writer: d.writer(w), check: m.onNested}
+	}
+	if m.omitEmpty {
+		d := *m
+		d.omitEmpty = false
+		return &emptyWriter{open: func() io.Writer { return d.writer(w) }}
+	}
+	if m.armor != ArmorNone {
+		enc, lw := m.armorEncoder(w)
+		d := *m
+		d.armor = ArmorNone
+		return &armoredWriter{Writer: d.writer(enc), enc: enc, lw: lw}
+	}
+	// Filters run before the lazy writers, so the sample reflects what is compressed
+	if m.shuffleWidth > 0 {
+		d := *m
+		d.shuffleWidth = 0
+		return &shuffleWriter{Writer: d.writer(w), width: m.shuffleWidth}
+	}
+	if m.deltaFilter {
+		d := *m
+		d.deltaFilter = false
+		return &deltaWriter{Writer: d.writer(w)}
+	}
+	if m.selector != nil {
+		choose := func(sample []byte) (Algorithm, int) { return m.selector(m, sample) }
+		return &lazyWriter{m: m, w: w, choose: choose, tagged: true}
+	}
+	if m.autoLevel && (m.dictName != "" || m.streamHeader) {
+		// The stream header records the level, so it is written once chosen
+		return &lazyWriter{m: m, w: w, choose: m.chooseLevel, tagged: true}
+	}
+	if m.dictName != "" {
+		return m.namedDictionaryWriter(w)
+	}
+	if m.streamHeader {
+		if _, err := w.Write(marshalStreamHeader(streamHeader{algorithm: m.algorithm, level: m.level})); err != nil {
+			return &errWriter{err}
+		}
+		return m.derive(m.algorithm, m.level).writer(w)
+	}
+	if m.autoLevel {
+		return &lazyWriter{m: m, w: w, choose: m.chooseLevel}
+	}
+
+	var cw io.Writer
+	if m.padded() {
+		counter := &countingWriter{w: w}
+		cw = &paddingWriter{Writer: m.compressor(counter), m: m, cw: counter}
+	} else {
+		cw = m.compressor(w)
+	}
+	if m.seeds != nil && m.algorithm == Zlib && !m.trusted {
+		cw = &seedWriter{Writer: cw, m: m, tail: tailBuffer{size: m.seedSize}}
+	}
+	return cw
+}
+
+// compressor creates the compressing writer for the configured algorithm
+func (m *Middleware) compressor(w io.Writer) io.Writer {
+	if m.seekableFormat && m.algorithm == Gzip {
+		return m.seekableWriter(w)
+	}
+	if m.parallel > 0 && m.algorithm == Gzip {
+		return newParallelWriter(w, m.level, m.parallel, m.gzipHeader(), m.storedPassthrough)
+	}
+	if m.headerCRC && m.algorithm == Gzip {
+		w = &headerCRCWriter{w: w}
+	}
+	if m.trustedFor(m.algorithm) {
+		tw, err := newTrustedWriter(w, m.algorithm, m.level, m.gzipHeader().OS)
+		if err != nil {
+			panic(fmt.Errorf("failed to create compressor: %w", err))
+		}
+		return tw
+	}
+	if bw, ok := m.backendWriter(w); ok {
+		return bw
+	}
+
 	switch m.algorithm {
 	case Gzip:
 		gzipWriter, err := gzip.NewWriterLevel(w, m.level)
 		if err != nil {
-			panic("failed to create gzip writer: " + err.Error())
+			panic(fmt.Errorf("failed to create gzip writer: %w: %v", ErrInvalidLevel, err))
+		}
+		gzipWriter.Header = m.gzipHeader()
+		if m.recordsSize() {
+			gzipWriter.Header.Extra = sizeExtra(unknownSize)
 		}
 		return &gzipWriteCloser{gzipWriter}
 	case Zlib:
-		zlibWriter, err := zlib.NewWriterLevel(w, m.level)
+		zlibWriter, err := zlib.NewWriterLevelDict(w, m.level, m.writeSeed())
 		if err != nil {
-			panic("failed to create zlib writer: " + err.Error())
+			panic(fmt.Errorf("failed to create zlib writer: %w: %v", ErrInvalidLevel, err))
 		}
 		return &zlibWriteCloser{zlibWriter}
+	case Flate:
+		flateWriter, err := flate.NewWriterDict(w, m.level, m.dictionary)
+		if err != nil {
+			panic(fmt.Errorf("failed to create flate writer: %w: %v", ErrInvalidLevel, err))
+		}
+		return &flateWriteCloser{flateWriter}
+	case Bzip2:
+		return &errWriter{fmt.Errorf("bzip2: %w", ErrReadOnlyAlgorithm)}
+	case None:
+		return &passthroughWriter{w}
+	case RLE:
+		return &rleWriter{w: w}
+	case LZW:
+		order, litWidth := m.lzwSettings()
+		return lzw.NewWriter(w, order, litWidth)
 	default:
-		panic("unsupported compression algorithm")
+		return m.codecWriter(w)
 	}
 }
 
-// Reader wraps an io.Reader with decompression
+// Reader wraps an io.Reader with decompression. The stream header is read on
+// the first Read, so r may still be empty when Reader is called.
 func (m *Middleware) Reader(r io.Reader) io.Reader {
-	switch m.algorithm {
+	release := m.acquire()
+	var dr io.Reader = &deferredReader{open: func() io.Reader { return m.reader(r) }, src: r, m: m, underlying: m.underlying(r)}
+	if release != nil {
+		return &gatedReader{Reader: dr, release: release}
+	}
+	return dr
+}
+
+// reader creates the decompressor and layers the configured stream options around it
+func (m *Middleware) reader(r io.Reader) io.Reader {
+	src := &countingReader{r: m.limitInput(m.watchReader(m.readBuffering(r)))}
+	r = src
+	if seeker, ok := src.r.(io.Seeker); ok {
+		// Keep the source seekable so WithVerifyBeforeRelease can rewind it
+		r = &countingSeeker{countingReader: src, s: seeker}
+	}
+	if m.readProgress != nil {
+		r = &progressReader{r: r, fn: m.readProgress, total: m.compressedSize}
+	}
+	if m.armor != ArmorNone {
+		r = m.armorDecoder(r)
+	}
+	if m.omitEmpty {
+		var empty bool
+		if r, empty = emptyInput(r); empty {
+			return r
+		}
+	}
+	if m.verifyBeforeRelease {
+		return m.limitOutput(&classifiedReader{r: &verifiedReader{m: m, src: r}, m: m, src: src})
+	}
+	dr, err := m.decode(r)
+	if err != nil {
+		// Malformed input must never panic; report it from Read instead
+		return &errReader{m.streamError("open", classify(err), src.n, 0)}
+	}
+	return m.limitOutput(&classifiedReader{r: dr, m: m, src: src})
+}
+
+// deferredReader opens the decompressor on the first Read, so wrapping an
+// empty or not yet written buffer neither blocks nor fails. Header errors are
+// returned from Read.
+type deferredReader struct {
+	open       func() io.Reader
+	r          io.Reader
+	src        io.Reader // compressed input
+	m          *Middleware
+	underlying io.Closer
+	lastErr    error
+}
+
+func (d *deferredReader) Read(p []byte) (int, error) {
+	if d.r == nil {
+		d.r = d.open()
+	}
+	n, err := d.r.Read(p)
+	if err != nil && err != d.lastErr {
+		d.lastErr = d.m.report("read", err)
+	}
+	return n, err
+}
+
+func (d *deferredReader) Header() (*gzip.Header, bool) {
+	if d.r == nil {
+		d.r = d.open()
+	}
+	return gzipHeaderOf(d.r)
+}
+
+func (d *deferredReader) ExpectedSize() (int64, bool) {
+	return d.m.expectedSize(d.src, d)
+}
+
+func (d *deferredReader) Close() error {
+	var err error
+	if closer, ok := d.r.(io.Closer); ok {
+		err = closer.Close()
+	}
+	return d.m.report("close", closeUnderlying(err, d.underlying))
+}
+
+// decode creates a reader returning the decompressed stream, unwrapping nested layers if enabled
+func (m *Middleware) decode(r io.Reader) (io.Reader, error) {
+	if m.streamHeader && m.selector == nil && m.dictName == "" {
+		br := bufio.NewReader(r)
+		if magic, _ := br.Peek(len(streamMagic)); !bytes.Equal(magic, streamMagic) {
+			// Written before the header was enabled
+			return m.derive(m.algorithm, m.level).decode(br)
+		}
+		r = br
+	}
+	if m.selector != nil || m.dictName != "" || m.streamHeader {
+		h, err := readStreamHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		d, err := m.fromHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		return d.decode(r)
+	}
+
+	var src *bufio.Reader
+	if m.strictTrailer || m.sizeTrailer() {
+		src = m.strictSource(r)
+		r = src
+	}
+	dr, err := m.decompressor(m.algorithm, r)
+	if err != nil {
+		return nil, err
+	}
+	if m.sizeTrailer() {
+		dr = &sizeTrailerReader{Reader: dr, src: src}
+	}
+	if m.strictTrailer {
+		dr = &trailingReader{Reader: dr, src: src}
+	}
+	if m.seeds != nil && m.algorithm == Zlib && !m.trusted {
+		dr = &seedReader{Reader: dr, m: m, tail: tailBuffer{size: m.seedSize}}
+	}
+	if m.deltaFilter {
+		dr = &deltaReader{Reader: dr}
+	}
+	if m.shuffleWidth > 0 {
+		dr = newShuffleReader(dr, m.shuffleWidth)
+	}
+	if m.maxNesting > 0 {
+		dr = &nestedReader{m: m, r: dr}
+	}
+	return dr, nil
+}
+
+// decompressor creates a decompressing reader for the given algorithm
+func (m *Middleware) decompressor(algorithm Algorithm, r io.Reader) (io.Reader, error) {
+	if m.uncheckedFor(algorithm) {
+		trustedReader, err := newTrustedReader(r, algorithm, m.headerLimits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create reader: %w", err)
+		}
+		trustedReader.single = m.singleStream
+		return trustedReader, nil
+	}
+
+	switch algorithm {
 	case Gzip:
+		if m.onSkip != nil {
+			rr := newRecoveryReader(r, m.onSkip, m.headerLimits)
+			rr.single = m.singleStream
+			return rr, nil
+		}
+		if m.headerLimits != nil {
+			br := bufio.NewReaderSize(r, m.headerLimits.bufferSize())
+			if _, err := parseGzipHeader(br, *m.headerLimits); err != nil {
+				return &errReader{err}, nil
+			}
+			r = br
+		}
 		gzipReader, err := gzip.NewReader(r)
 		if err != nil {
-			panic("failed to create gzip reader: " + err.Error())
+			// Empty input is a stream cut short before its header
+			return nil, fmt.Errorf("failed to create gzip reader: %w", noEOF(err))
+		}
+		// Read members one at a time to check each header against the limits
+		if br, ok := r.(*bufio.Reader); ok && (m.strictTrailer || m.headerLimits != nil) {
+			gzipReader.Multistream(false)
+			return &gzipMemberReader{m: m, br: br, zr: gzipReader}, nil
 		}
-		return gzipReader
+		gzipReader.Multistream(!m.singleStream)
+		return gzipReader, nil
 	case Zlib:
-		zlibReader, err := zlib.NewReader(r)
+		zlibReader, err := m.zlibReader(r)
 		if err != nil {
-			panic("failed to create zlib reader: " + err.Error())
+			return nil, fmt.Errorf("failed to create zlib reader: %w", err)
 		}
-		return &zlibReadCloser{zlibReader}
+		return &zlibReadCloser{zlibReader}, nil
+	case Flate:
+		return flate.NewReaderDict(r, m.dictionary), nil
+	case Bzip2:
+		return bzip2.NewReader(r), nil
+	case None:
+		return r, nil
+	case RLE:
+		return newRLEReader(r), nil
+	case LZW:
+		order, litWidth := m.lzwSettings()
+		return lzw.NewReader(r, order, litWidth), nil
 	default:
-		panic("unsupported compression algorithm")
+		return codecReader(algorithm, r)
 	}
 }
 
@@ -128,6 +606,35 @@ func (w *zlibWriteCloser) Close() error {
 	return nil
 }
 
+// flateWriteCloser wraps flate.Writer to ensure proper closing
+type flateWriteCloser struct {
+	*flate.Writer
+}
+
+func (w *flateWriteCloser) Write(p []byte) (n int, err error) {
+	return w.Writer.Write(p)
+}
+
+func (w *flateWriteCloser) Close() error {
+	if err := w.Writer.Close(); err != nil {
+		return fmt.Errorf("failed to close flate writer: %w", err)
+	}
+	return nil
+}
+
+// passthroughWriter writes data unchanged. Close does not close the underlying writer.
+type passthroughWriter struct {
+	io.Writer
+}
+
+func (w *passthroughWriter) Flush() error {
+	return flush(w.Writer)
+}
+
+func (w *passthroughWriter) Close() error {
+	return nil
+}
+
 // zlibReadCloser wraps zlib reader to implement io.ReadCloser
 type zlibReadCloser struct {
 	io.ReadCloser
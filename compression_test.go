@@ -2,7 +2,11 @@ package compressionstdlib
 
 import (
 	"bytes"
+	"compress/flate"
+	"encoding/hex"
+	"errors"
 	"io"
+	"strings"
 	"testing"
 )
 
@@ -30,6 +34,86 @@ func TestNew_InvalidLevel(t *testing.T) {
 	}
 }
 
+func TestNew_LevelConstants(t *testing.T) {
+	if m := New(Gzip, WithLevel(BestSpeed)); m.level != 1 {
+		t.Fatalf("Expected level 1 for BestSpeed, got %d", m.level)
+	}
+	if m := New(Zlib, WithLevel(BestCompression)); m.level != 9 {
+		t.Fatalf("Expected level 9 for BestCompression, got %d", m.level)
+	}
+	m, err := NewE(Gzip, WithLevel(BestSpeed), WithLevel(DefaultCompression))
+	if err != nil {
+		t.Fatalf("Expected DefaultCompression to be valid: %v", err)
+	}
+	if m.level != 6 {
+		t.Fatalf("Expected default level 6 for DefaultCompression, got %d", m.level)
+	}
+	if m := New(Flate, WithLevel(HuffmanOnly)); m.level != HuffmanOnly {
+		t.Fatalf("Expected HuffmanOnly, got %d", m.level)
+	}
+}
+
+func TestNoCompressionLevel(t *testing.T) {
+	data := bytes.Repeat([]byte("stored, not deflated "), 1000)
+	for _, alg := range []Algorithm{Gzip, Zlib, Flate} {
+		m, err := NewE(alg, WithLevel(NoCompression), WithStrictLevel())
+		if err != nil {
+			t.Fatalf("%v: expected level 0 to be valid: %v", alg, err)
+		}
+		compressed := compressWith(t, m, data)
+		if len(compressed) <= len(data) {
+			t.Fatalf("%v: expected stored output of at least %d bytes, got %d", alg, len(data), len(compressed))
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%v: failed to read: %v", alg, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: data mismatch", alg)
+		}
+	}
+}
+
+func TestNew_BrotliLevelRange(t *testing.T) {
+	// Brotli accepts levels 0-11, the deflate based algorithms only 0-9
+	for _, level := range []int{0, 11} {
+		if m := New(Brotli, WithLevel(level)); m.level != level {
+			t.Fatalf("Expected Brotli level %d, got %d", level, m.level)
+		}
+	}
+	if m := New(Gzip, WithLevel(11)); m.level != 6 {
+		t.Fatalf("Expected default level 6 for gzip level 11, got %d", m.level)
+	}
+	if m := New(Brotli, WithLevel(12)); m.level != 6 {
+		t.Fatalf("Expected default level 6 for invalid Brotli level, got %d", m.level)
+	}
+}
+
+func TestHuffmanOnly(t *testing.T) {
+	data := bytes.Repeat([]byte("entropy coding only "), 500)
+
+	for _, algorithm := range []Algorithm{Gzip, Zlib, Flate} {
+		m := New(algorithm, WithLevel(flate.HuffmanOnly))
+		if m.level != flate.HuffmanOnly {
+			t.Fatalf("Algorithm %d: expected HuffmanOnly level, got %d", algorithm, m.level)
+		}
+
+		// Without LZ matching, repetitive data compresses far worse than at level 1
+		huffman := compressWith(t, m, data)
+		if fast := compressWith(t, New(algorithm, WithLevel(1)), data); len(huffman) <= len(fast) {
+			t.Fatalf("Algorithm %d: expected HuffmanOnly output larger than level 1, got %d <= %d", algorithm, len(huffman), len(fast))
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(huffman)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("Algorithm %d: round trip failed: %v", algorithm, err)
+		}
+	}
+
+	if m := New(RLE, WithLevel(flate.HuffmanOnly)); m.level != 6 {
+		t.Fatalf("Expected HuffmanOnly to be ignored for RLE, got level %d", m.level)
+	}
+}
+
 func TestGzipCompression(t *testing.T) {
 	testCompressionAlgorithm(t, Gzip, "Gzip")
 }
@@ -38,6 +122,48 @@ func TestZlibCompression(t *testing.T) {
 	testCompressionAlgorithm(t, Zlib, "Zlib")
 }
 
+func TestFlateCompression(t *testing.T) {
+	testCompressionAlgorithm(t, Flate, "Flate")
+}
+
+func TestFlateOmitsFraming(t *testing.T) {
+	data := bytes.Repeat([]byte("raw deflate "), 100)
+
+	raw := compressWith(t, New(Flate), data)
+	framed := compressWith(t, New(Zlib), data)
+	// zlib adds a 2 byte header and a 4 byte Adler-32 trailer around the same deflate data
+	if len(framed)-len(raw) != 6 {
+		t.Fatalf("Expected flate to be 6 bytes smaller than zlib, got %d vs %d", len(raw), len(framed))
+	}
+
+	got, err := io.ReadAll(flate.NewReader(bytes.NewReader(raw)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("compress/flate failed to read output: %v", err)
+	}
+
+	// Raw deflate has no checksums, so the trusted pipeline leaves it unchanged
+	trusted := New(Flate, WithTrustedPipeline())
+	got, err = io.ReadAll(trusted.Reader(bytes.NewReader(compressWith(t, trusted, data))))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Trusted flate round trip failed: %v", err)
+	}
+}
+
+func TestNonePassthrough(t *testing.T) {
+	data := []byte("stored as is")
+
+	m := New(None, WithPaddingBuckets(64))
+	compressed := compressWith(t, m, data)
+	if !bytes.Equal(compressed, data) {
+		t.Fatalf("Expected data to pass through unchanged, got %q", compressed)
+	}
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Passthrough read failed: %v", err)
+	}
+}
+
 func testCompressionAlgorithm(t *testing.T, algorithm Algorithm, name string) {
 	m := New(algorithm)
 
@@ -254,4 +380,163 @@ func TestEmptyData(t *testing.T) {
 	if len(decompressedData) != 0 {
 		t.Fatalf("Expected empty data, got %d bytes", len(decompressedData))
 	}
-}
\ No newline at end of file
+}
+func TestMalformedInputDoesNotPanic(t *testing.T) {
+	// Test that malformed headers surface as read errors instead of panics
+	inputs := [][]byte{
+		nil,
+		[]byte("not compressed at all"),
+		{0x1f, 0x8b},
+		{0x78, 0x9c, 0xff},
+	}
+
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		for _, input := range inputs {
+			_, err := io.ReadAll(New(algorithm).Reader(bytes.NewReader(input)))
+			if err == nil {
+				t.Fatalf("Algorithm %d: Expected error for malformed input %q", algorithm, input)
+			}
+		}
+	}
+}
+
+func FuzzGzipReader(f *testing.F) {
+	fuzzReader(f, Gzip)
+}
+
+func FuzzZlibReader(f *testing.F) {
+	fuzzReader(f, Zlib)
+}
+
+func FuzzFlateReader(f *testing.F) {
+	fuzzReader(f, Flate)
+}
+
+// fuzzReader feeds arbitrary input through the middleware readers, which must
+// only ever return errors and never panic
+func fuzzReader(f *testing.F, algorithm Algorithm) {
+	payload := bytes.Repeat([]byte("fuzz seed data "), 16)
+	for _, m := range []*Middleware{New(algorithm), New(algorithm, WithLevel(1))} {
+		var buf bytes.Buffer
+		w := m.Writer(&buf)
+		w.Write(payload)
+		w.(io.Closer).Close()
+		f.Add(buf.Bytes())
+		f.Add(buf.Bytes()[:buf.Len()/2])
+	}
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		middlewares := []*Middleware{
+			New(algorithm),
+			New(algorithm, WithHeaderLimits(16, 16, 16), WithMaxNestingDepth(2), WithMaxConcurrentStreams(1)),
+			New(algorithm, WithTrustedPipeline()),
+			New(algorithm, WithSkipChecksum(), WithStrictTrailer(), WithMaxDecompressedSize(1<<16)),
+			NewLazy(),
+		}
+		for _, m := range middlewares {
+			r := m.Reader(bytes.NewReader(data))
+			io.Copy(io.Discard, io.LimitReader(r, 1<<20))
+			if closer, ok := r.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	})
+}
+
+func TestBzip2ReadOnly(t *testing.T) {
+	// Produced by the bzip2 command line tool
+	compressed, _ := hex.DecodeString("425a68393141592653599e266081000014d9800010400010003ea4c0" +
+		"30200022bfd5531304790a60003085d0e21565aa21fa5f38ba594b49745dc914e1424278998204")
+
+	m := New(Bzip2)
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to read bzip2 data: %v", err)
+	}
+	if want := strings.Repeat("legacy bzip2 payload\n", 3); string(got) != want {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+
+	w := m.Writer(&bytes.Buffer{})
+	if _, err := w.Write([]byte("data")); !errors.Is(err, ErrReadOnlyAlgorithm) {
+		t.Fatalf("Expected ErrReadOnlyAlgorithm from Write, got %v", err)
+	}
+	if err := w.(io.Closer).Close(); !errors.Is(err, ErrReadOnlyAlgorithm) {
+		t.Fatalf("Expected ErrReadOnlyAlgorithm from Close, got %v", err)
+	}
+}
+
+func TestReaderDeferredInit(t *testing.T) {
+	var buf bytes.Buffer
+	m := New(Gzip)
+	r := m.Reader(&buf) // nothing written yet
+
+	data := []byte("written after the reader was created")
+	buf.Write(compressWith(t, m, data))
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+
+	// An empty input surfaces the header error from Read
+	if _, err := io.ReadAll(m.Reader(&bytes.Buffer{})); err == nil {
+		t.Fatal("Expected error reading empty input")
+	}
+}
+
+func TestStrictLevel(t *testing.T) {
+	// Without strict mode an invalid level falls back to the default
+	m := New(Gzip, WithLevel(15))
+	if m.level != 6 {
+		t.Fatalf("Expected default level, got %d", m.level)
+	}
+	m.Writer(&bytes.Buffer{})
+
+	strict := New(Gzip, WithLevel(15), WithStrictLevel())
+	if _, err := strict.NewWriter(&bytes.Buffer{}); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("Expected ErrInvalidLevel, got %v", err)
+	}
+	if _, err := NewE(Gzip, WithLevel(15)); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("Expected NewE to reject the level, got %v", err)
+	}
+
+	// A later valid level replaces the invalid one
+	if _, err := New(Gzip, WithLevel(15), WithLevel(9), WithStrictLevel()).NewWriter(&bytes.Buffer{}); err != nil {
+		t.Fatalf("Expected valid level to be accepted, got %v", err)
+	}
+	if _, err := NewE(Brotli, WithLevel(11)); err != nil && !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Fatalf("Expected Brotli level 11 to be valid, got %v", err)
+	}
+}
+
+func TestConvenienceConstructors(t *testing.T) {
+	data := bytes.Repeat([]byte("convenience "), 100)
+	tests := []struct {
+		m    *Middleware
+		want Algorithm
+	}{
+		{NewGzip(), Gzip},
+		{NewZlib(WithLevel(1)), Zlib},
+	}
+	for _, tt := range tests {
+		if tt.m.algorithm != tt.want {
+			t.Fatalf("Expected %v, got %v", tt.want, tt.m.algorithm)
+		}
+		compressed := compressWith(t, tt.m, data)
+		got, err := io.ReadAll(New(tt.want).Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%v: Failed to read: %v", tt.want, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: Data mismatch", tt.want)
+		}
+	}
+	if NewZlib(WithLevel(1)).level != 1 {
+		t.Fatal("Options were not applied")
+	}
+}
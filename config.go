@@ -0,0 +1,153 @@
+package compressionstdlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Config describes a middleware declaratively, as an alternative to passing
+// options to NewE. The zero value of each field leaves the corresponding
+// setting at its default; see the option of the same name for details.
+// Level is a pointer, so that a nil Level selects the default level while
+// level 0 stores data uncompressed.
+//
+// Config can be loaded from JSON or YAML configuration files. Algorithm and
+// Backend are written as names, such as "gzip", and StallTimeout as a
+// duration string, such as "30s".
+type Config struct {
+	Algorithm Algorithm `json:"algorithm" yaml:"algorithm"`
+	// Level is the compression level; nil selects the default level and
+	// NoCompression (0) stores data uncompressed
+	Level       *int    `json:"level,omitempty" yaml:"level,omitempty"`
+	StrictLevel bool    `json:"strict_level,omitempty" yaml:"strict_level,omitempty"`
+	Backend     Backend `json:"backend,omitempty" yaml:"backend,omitempty"`
+
+	DictionaryName string `json:"dictionary_name,omitempty" yaml:"dictionary_name,omitempty"`
+	StreamHeader   bool   `json:"stream_header,omitempty" yaml:"stream_header,omitempty"`
+	SeekableFormat bool   `json:"seekable_format,omitempty" yaml:"seekable_format,omitempty"`
+	Parallel       int    `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+	Deterministic  bool   `json:"deterministic,omitempty" yaml:"deterministic,omitempty"`
+	OriginalSize   bool   `json:"original_size,omitempty" yaml:"original_size,omitempty"`
+
+	MaxDecompressedSize  int64         `json:"max_decompressed_size,omitempty" yaml:"max_decompressed_size,omitempty"`
+	MaxCompressedInput   int64         `json:"max_compressed_input,omitempty" yaml:"max_compressed_input,omitempty"`
+	StrictTrailer        bool          `json:"strict_trailer,omitempty" yaml:"strict_trailer,omitempty"`
+	MaxConcurrentStreams int           `json:"max_concurrent_streams,omitempty" yaml:"max_concurrent_streams,omitempty"`
+	StallTimeout         time.Duration `json:"stall_timeout,omitempty" yaml:"stall_timeout,omitempty"`
+	CloseUnderlying      bool          `json:"close_underlying,omitempty" yaml:"close_underlying,omitempty"`
+
+	FlushEvery      int `json:"flush_every,omitempty" yaml:"flush_every,omitempty"`
+	ReadBufferSize  int `json:"read_buffer_size,omitempty" yaml:"read_buffer_size,omitempty"`
+	WriteBufferSize int `json:"write_buffer_size,omitempty" yaml:"write_buffer_size,omitempty"`
+
+	// Options are applied after the fields, for settings that cannot be
+	// expressed as data, such as WithErrorHandler
+	Options []Option `json:"-" yaml:"-"`
+}
+
+// NewFromConfig creates the middleware described by cfg; see Config.Build
+func NewFromConfig(cfg Config) (*Middleware, error) {
+	return cfg.Build()
+}
+
+// jsonConfig is the JSON form of Config, with the stall timeout as a duration string
+type jsonConfig struct {
+	config
+	StallTimeout string `json:"stall_timeout,omitempty"`
+}
+
+// config has the fields of Config without its methods
+type config Config
+
+// MarshalJSON implements json.Marshaler
+func (c Config) MarshalJSON() ([]byte, error) {
+	jc := jsonConfig{config: config(c)}
+	if c.StallTimeout != 0 {
+		jc.StallTimeout = c.StallTimeout.String()
+	}
+	return json.Marshal(jc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (c *Config) UnmarshalJSON(data []byte) error {
+	jc := jsonConfig{config: config(*c)}
+	if err := json.Unmarshal(data, &jc); err != nil {
+		return err
+	}
+	*c = Config(jc.config)
+	if jc.StallTimeout != "" {
+		d, err := time.ParseDuration(jc.StallTimeout)
+		if err != nil {
+			return fmt.Errorf("%w: stall_timeout: %v", ErrInvalidOption, err)
+		}
+		c.StallTimeout = d
+	}
+	return nil
+}
+
+// Build creates the middleware described by c. Like NewE, it rejects invalid
+// levels and conflicting settings.
+func (c Config) Build() (*Middleware, error) {
+	return NewE(c.Algorithm, c.options()...)
+}
+
+// options translates the fields of c into options
+func (c Config) options() []Option {
+	var opts []Option
+	if c.Level != nil {
+		opts = append(opts, WithLevel(*c.Level))
+	}
+	if c.StrictLevel {
+		opts = append(opts, WithStrictLevel())
+	}
+	if c.Backend != BackendStdlib {
+		opts = append(opts, WithBackend(c.Backend))
+	}
+	if c.DictionaryName != "" {
+		opts = append(opts, WithDictionaryName(c.DictionaryName))
+	}
+	if c.StreamHeader {
+		opts = append(opts, WithStreamHeader())
+	}
+	if c.SeekableFormat {
+		opts = append(opts, WithSeekableFormat())
+	}
+	if c.Parallel != 0 {
+		opts = append(opts, WithParallel(c.Parallel))
+	}
+	if c.Deterministic {
+		opts = append(opts, WithDeterministic())
+	}
+	if c.OriginalSize {
+		opts = append(opts, WithOriginalSize())
+	}
+	if c.MaxDecompressedSize != 0 {
+		opts = append(opts, WithMaxDecompressedSize(c.MaxDecompressedSize))
+	}
+	if c.MaxCompressedInput != 0 {
+		opts = append(opts, WithMaxCompressedInput(c.MaxCompressedInput))
+	}
+	if c.StrictTrailer {
+		opts = append(opts, WithStrictTrailer())
+	}
+	if c.MaxConcurrentStreams != 0 {
+		opts = append(opts, WithMaxConcurrentStreams(c.MaxConcurrentStreams))
+	}
+	if c.StallTimeout != 0 {
+		opts = append(opts, WithStallTimeout(c.StallTimeout))
+	}
+	if c.CloseUnderlying {
+		opts = append(opts, WithCloseUnderlying())
+	}
+	if c.FlushEvery != 0 {
+		opts = append(opts, WithFlushEvery(c.FlushEvery))
+	}
+	if c.ReadBufferSize != 0 {
+		opts = append(opts, WithReadBufferSize(c.ReadBufferSize))
+	}
+	if c.WriteBufferSize != 0 {
+		opts = append(opts, WithWriteBufferSize(c.WriteBufferSize))
+	}
+	return append(opts, c.Options...)
+}
@@ -0,0 +1,140 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestConfigBuild(t *testing.T) {
+	var reported []string
+	level := BestCompression
+	cfg := Config{
+		Algorithm:           Zlib,
+		Level:               &level,
+		MaxDecompressedSize: 1 << 20,
+		StrictTrailer:       true,
+		OriginalSize:        true,
+		Options: []Option{WithErrorHandler(func(op string, err error) {
+			reported = append(reported, op)
+		})},
+	}
+	m, err := cfg.Build()
+	if err != nil {
+		t.Fatalf("Failed to build: %v", err)
+	}
+	if m.algorithm != Zlib || m.level != 9 || m.maxDecompressed != 1<<20 || !m.strictTrailer || !m.originalSize {
+		t.Fatalf("Unexpected middleware %+v", m)
+	}
+
+	data := bytes.Repeat([]byte("declarative "), 100)
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if _, err := io.ReadAll(m.Reader(bytes.NewReader([]byte("garbage")))); err == nil || len(reported) == 0 {
+		t.Fatalf("Expected the error handler from Options to be called, got %v", err)
+	}
+
+	// A nil level selects the default, while level 0 stores data uncompressed
+	if m, err := (Config{Algorithm: Gzip}).Build(); err != nil || m.level != 6 {
+		t.Fatalf("Expected default level 6, got %v", err)
+	}
+	stored := NoCompression
+	m, err = (Config{Algorithm: Gzip, Level: &stored}).Build()
+	if err != nil || m.level != NoCompression {
+		t.Fatalf("Expected level 0, got %v", err)
+	}
+	compressed := compressWith(t, m, data)
+	if !bytes.Contains(compressed, data[:100]) {
+		t.Fatal("Expected data to be stored uncompressed at level 0")
+	}
+}
+
+func TestConfigBuildInvalid(t *testing.T) {
+	level := 12
+	if _, err := (Config{Algorithm: Gzip, Level: &level}).Build(); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("Expected ErrInvalidLevel, got %v", err)
+	}
+	if _, err := (Config{Algorithm: Gzip, SeekableFormat: true, Parallel: 4}).Build(); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Expected ErrInvalidOption, got %v", err)
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	var cfg Config
+	input := `{
+		"algorithm": "zlib",
+		"level": 9,
+		"backend": "stdlib",
+		"max_decompressed_size": 1048576,
+		"stall_timeout": "30s",
+		"write_buffer_size": 65536
+	}`
+	if err := json.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	level := 9
+	want := Config{
+		Algorithm:           Zlib,
+		Level:               &level,
+		MaxDecompressedSize: 1 << 20,
+		StallTimeout:        30 * time.Second,
+		WriteBufferSize:     64 << 10,
+	}
+	if cfg.Algorithm != want.Algorithm || cfg.Level == nil || *cfg.Level != *want.Level || cfg.MaxDecompressedSize != want.MaxDecompressedSize ||
+		cfg.StallTimeout != want.StallTimeout || cfg.WriteBufferSize != want.WriteBufferSize {
+		t.Fatalf("Expected %+v, got %+v", want, cfg)
+	}
+
+	m, err := NewFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("Failed to build: %v", err)
+	}
+	if m.algorithm != Zlib || m.stallTimeout != 30*time.Second || m.writeBufferSize != 64<<10 {
+		t.Fatalf("Unexpected middleware %+v", m)
+	}
+
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if !bytes.Contains(out, []byte(`"algorithm":"zlib"`)) || !bytes.Contains(out, []byte(`"stall_timeout":"30s"`)) {
+		t.Fatalf("Unexpected JSON %s", out)
+	}
+	var back Config
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatalf("Failed to unmarshal marshaled config: %v", err)
+	}
+	if back.Algorithm != cfg.Algorithm || back.StallTimeout != cfg.StallTimeout {
+		t.Fatalf("Expected %+v after a round trip, got %+v", cfg, back)
+	}
+
+	// An explicit level 0 is kept, unlike an omitted level
+	var stored Config
+	if err := json.Unmarshal([]byte(`{"algorithm": "gzip", "level": 0}`), &stored); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if m, err := stored.Build(); err != nil || m.level != NoCompression {
+		t.Fatalf("Expected level 0, got %+v, %v", stored, err)
+	}
+	if out, err := json.Marshal(stored); err != nil || !bytes.Contains(out, []byte(`"level":0`)) {
+		t.Fatalf("Expected level 0 to be marshaled, got %s, %v", out, err)
+	}
+}
+
+func TestConfigJSONInvalid(t *testing.T) {
+	for _, input := range []string{
+		`{"algorithm": "lzma"}`,
+		`{"backend": "cgo"}`,
+		`{"stall_timeout": "soon"}`,
+	} {
+		var cfg Config
+		if err := json.Unmarshal([]byte(input), &cfg); err == nil {
+			t.Fatalf("Expected an error for %s", input)
+		}
+	}
+}
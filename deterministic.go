@@ -0,0 +1,24 @@
+package compressionstdlib
+
+import "compress/gzip"
+
+// WithDeterministic makes gzip output reproducible across machines: the
+// modification time and the OS byte of every member header are zero, so
+// identical input and options always produce byte-identical output, as needed
+// for content-addressed storage. Gzip streams are then written by the standard
+// library even if another backend is selected. Zlib and flate streams carry no
+// such fields and are always deterministic.
+func WithDeterministic() Option {
+	return func(m *Middleware) {
+		m.deterministic = true
+	}
+}
+
+// gzipHeader returns the header written with gzip members
+func (m *Middleware) gzipHeader() gzip.Header {
+	if m.deterministic {
+		return gzip.Header{OS: 0}
+	}
+	// The standard library default: no modification time, unknown OS
+	return gzip.Header{OS: 255}
+}
@@ -0,0 +1,53 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestDeterministic(t *testing.T) {
+	data := bytes.Repeat([]byte("content addressed "), 100000)
+
+	for _, m := range []*Middleware{
+		New(Gzip, WithDeterministic()),
+		New(Gzip, WithDeterministic(), WithParallel(4)),
+		New(Gzip, WithDeterministic(), WithSeekableFormat()),
+		New(Gzip, WithDeterministic(), WithTrustedPipeline()),
+	} {
+		first := compressWith(t, m, data)
+		second := compressWith(t, m, data)
+		if !bytes.Equal(first, second) {
+			t.Fatal("Expected byte-identical output")
+		}
+
+		// Every member header has a zero modification time and OS byte
+		zr, err := m.NewReader(bytes.NewReader(first))
+		if err != nil {
+			t.Fatalf("Failed to open: %v", err)
+		}
+		if got, err := io.ReadAll(zr); err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("Failed to read back: %v", err)
+		}
+		if !bytes.Equal(first[4:10], []byte{0, 0, 0, 0, first[8], 0}) {
+			t.Fatalf("Expected zero MTIME and OS, got header % x", first[:10])
+		}
+	}
+
+	// Resettable writers produce the same output
+	var buf bytes.Buffer
+	m := New(Gzip, WithDeterministic())
+	rw, err := m.NewResettableWriter(io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	rw.Close()
+	rw.Reset(&buf)
+	rw.Write(data)
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), compressWith(t, m, data)) {
+		t.Fatal("Expected resettable writer output to match")
+	}
+}
@@ -0,0 +1,82 @@
+package compressionstdlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrUnknownDictionary is returned when a stream references a dictionary that is not registered
+var ErrUnknownDictionary = errors.New("unknown dictionary")
+
+// maxDictionaryName is the longest dictionary name a stream header can carry
+const maxDictionaryName = 255
+
+var (
+	dictionariesMu sync.RWMutex
+	dictionaries   = make(map[string][]byte)
+)
+
+// WithDictionary sets a preset dictionary for zlib and flate streams. Data
+// resembling the dictionary compresses much better, which matters most for
+// small payloads. Readers must be configured with the same dictionary. Gzip has
+// no preset dictionary support and ignores it.
+func WithDictionary(dict []byte) Option {
+	return func(m *Middleware) {
+		m.dictionary = dict
+	}
+}
+
+// RegisterDictionary makes a preset dictionary available under name for
+// WithDictionaryName and for readers resolving named streams. Names are 1-255
+// bytes; like the database/sql driver registry it panics if the name is
+// invalid or already registered, so versioned names such as "events-v2"
+// should be used when a dictionary changes.
+func RegisterDictionary(name string, dict []byte) {
+	if name == "" || len(name) > maxDictionaryName {
+		panic(fmt.Sprintf("compression: invalid dictionary name %q", name))
+	}
+	dictionariesMu.Lock()
+	defer dictionariesMu.Unlock()
+	if _, dup := dictionaries[name]; dup {
+		panic(fmt.Sprintf("compression: dictionary %q registered twice", name))
+	}
+	dictionaries[name] = dict
+}
+
+func lookupDictionary(name string) ([]byte, bool) {
+	dictionariesMu.RLock()
+	defer dictionariesMu.RUnlock()
+	dict, ok := dictionaries[name]
+	return dict, ok
+}
+
+// WithDictionaryName compresses zlib and flate streams with the dictionary
+// registered under name and records the name in a small stream header, so
+// readers resolve the right dictionary automatically. The writer panics if the
+// name is not registered.
+func WithDictionaryName(name string) Option {
+	return func(m *Middleware) {
+		m.dictName = name
+	}
+}
+
+// namedDictionaryWriter writes the stream header naming the dictionary and compresses with it
+func (m *Middleware) namedDictionaryWriter(w io.Writer) io.Writer {
+	d := m.derive(m.algorithm, m.level)
+	d.dictionary = m.namedDictionary()
+	if _, err := w.Write(marshalStreamHeader(streamHeader{algorithm: m.algorithm, level: m.level, dictionary: m.dictName})); err != nil {
+		return &errWriter{err}
+	}
+	return d.writer(w)
+}
+
+// namedDictionary resolves the dictionary configured with WithDictionaryName
+func (m *Middleware) namedDictionary() []byte {
+	dict, ok := lookupDictionary(m.dictName)
+	if !ok {
+		panic(fmt.Errorf("compression: %w: %q", ErrUnknownDictionary, m.dictName))
+	}
+	return dict
+}
@@ -0,0 +1,101 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDictionary(t *testing.T) {
+	dict := []byte(`{"event":"page_view","session_id":"","user_agent":"Mozilla/5.0 (X11; Linux x86_64)","referrer":"https://example.com/","country":"DE"}`)
+	data := []byte(`{"event":"page_view","session_id":"a81f","user_agent":"Mozilla/5.0 (X11; Linux x86_64)","referrer":"https://example.com/docs","country":"AT"}`)
+
+	for _, algorithm := range []Algorithm{Zlib, Flate} {
+		m := New(algorithm, WithDictionary(dict))
+		withDict := compressWith(t, m, data)
+		if plain := compressWith(t, New(algorithm), data); len(withDict) >= len(plain) {
+			t.Fatalf("Algorithm %d: expected dictionary to improve ratio, got %d >= %d", algorithm, len(withDict), len(plain))
+		}
+
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(withDict)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("Algorithm %d: round trip failed: %v", algorithm, err)
+		}
+	}
+}
+
+func TestDictionaryMismatch(t *testing.T) {
+	compressed := compressWith(t, New(Zlib, WithDictionary([]byte("writer dictionary"))), []byte("payload"))
+
+	_, err := io.ReadAll(New(Zlib, WithDictionary([]byte("other dictionary"))).Reader(bytes.NewReader(compressed)))
+	if !errors.Is(err, zlib.ErrDictionary) {
+		t.Fatalf("Expected zlib.ErrDictionary, got %v", err)
+	}
+}
+
+func TestDictionaryTrusted(t *testing.T) {
+	// The trusted pipeline defers zlib streams with a dictionary to the standard library
+	m := New(Zlib, WithDictionary([]byte("shared prefix")), WithTrustedPipeline())
+	data := []byte("shared prefix and more")
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Round trip failed: %v", err)
+	}
+}
+
+// registerOnce registers a dictionary unless an earlier test run already did
+func registerOnce(name string, dict []byte) {
+	if _, ok := lookupDictionary(name); !ok {
+		RegisterDictionary(name, dict)
+	}
+}
+
+func TestDictionaryName(t *testing.T) {
+	registerOnce("events-v1", []byte(`{"event":"page_view","user_agent":"Mozilla/5.0 (X11; Linux x86_64)","region":"eu-central-1"}`))
+	data := []byte(`{"event":"page_view","user_agent":"Mozilla/5.0 (X11; Linux x86_64)","region":"eu-central-1","id":1}`)
+
+	for _, m := range []*Middleware{
+		New(Zlib, WithDictionaryName("events-v1")),
+		New(Auto, WithDictionaryName("events-v1")),
+	} {
+		compressed := compressWith(t, m, data)
+		if compressed[7]&streamFlagDictionary == 0 {
+			t.Fatal("Expected stream header naming the dictionary")
+		}
+
+		// Any reader reading stream headers resolves the dictionary by name
+		for _, r := range []*Middleware{m, NewLazy(), New(Flate, WithDictionaryName("events-v1"))} {
+			got, err := io.ReadAll(r.Reader(bytes.NewReader(compressed)))
+			if err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("Round trip failed: %v", err)
+			}
+		}
+		if err := Verify(bytes.NewReader(compressed), VerifyDeep); err != nil {
+			t.Fatalf("Expected named stream to verify, got %v", err)
+		}
+	}
+}
+
+func TestDictionaryNameUnknown(t *testing.T) {
+	registerOnce("retired", []byte("old dictionary"))
+	compressed := compressWith(t, New(Zlib, WithDictionaryName("retired")), []byte("payload"))
+
+	// Rename the dictionary in the header to one that is not registered
+	renamed := bytes.Replace(compressed, []byte("retired"), []byte("missing"), 1)
+	_, err := io.ReadAll(NewLazy().Reader(bytes.NewReader(renamed)))
+	if !errors.Is(err, ErrUnknownDictionary) {
+		t.Fatalf("Expected ErrUnknownDictionary, got %v", err)
+	}
+}
+
+func TestRegisterDictionaryTwice(t *testing.T) {
+	registerOnce("twice", nil)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("Expected panic registering a dictionary name twice")
+		}
+	}()
+	RegisterDictionary("twice", nil)
+}
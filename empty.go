@@ -0,0 +1,56 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"io"
+)
+
+// WithOmitEmptyStream makes closing a writer that never received data write
+// nothing to the underlying writer, and makes readers treat zero-byte input as
+// an empty stream. Without it, every writer produces a complete, valid stream
+// on Close even when no data was written, and zero-byte input is rejected as
+// truncated.
+func WithOmitEmptyStream() Option {
+	return func(m *Middleware) {
+		m.omitEmpty = true
+	}
+}
+
+// emptyWriter defers creating the compressor until the first non-empty write
+type emptyWriter struct {
+	open func() io.Writer
+	cw   io.Writer
+}
+
+func (w *emptyWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 && w.cw == nil {
+		return 0, nil
+	}
+	if w.cw == nil {
+		w.cw = w.open()
+	}
+	return w.cw.Write(p)
+}
+
+func (w *emptyWriter) Flush() error {
+	if w.cw == nil {
+		return nil
+	}
+	return flush(w.cw)
+}
+
+func (w *emptyWriter) Close() error {
+	if closer, ok := w.cw.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// emptyInput reports whether r holds no data, returning a reader positioned at its start
+func emptyInput(r io.Reader) (io.Reader, bool) {
+	br := bufio.NewReader(r)
+	if _, err := br.Peek(1); err == io.EOF {
+		return br, true
+	}
+	return br, false
+}
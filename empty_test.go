@@ -0,0 +1,61 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestOmitEmptyStream(t *testing.T) {
+	for _, m := range []*Middleware{
+		New(Gzip, WithOmitEmptyStream()),
+		New(Zlib, WithOmitEmptyStream()),
+		New(Gzip, WithOmitEmptyStream(), WithArmor(ArmorBase64, 76)),
+		NewLazy(WithOmitEmptyStream()),
+	} {
+		var buf bytes.Buffer
+		w := m.Writer(&buf)
+		if _, err := w.Write(nil); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		if err := w.(io.Closer).Close(); err != nil {
+			t.Fatalf("Failed to close: %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Fatalf("Expected no output for empty stream, got %d bytes", buf.Len())
+		}
+
+		got, err := io.ReadAll(m.Reader(&buf))
+		if err != nil {
+			t.Fatalf("Expected zero-byte input to read as empty, got %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("Expected no data, got %d bytes", len(got))
+		}
+
+		data := []byte("not empty")
+		got, err = io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+		if err != nil {
+			t.Fatalf("Failed to read stream: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatal("Data mismatch")
+		}
+	}
+}
+
+func TestEmptyStreamWrittenByDefault(t *testing.T) {
+	for _, m := range []*Middleware{New(Gzip), New(Zlib), NewLazy()} {
+		compressed := compressWith(t, m, nil)
+		if len(compressed) == 0 {
+			t.Fatal("Expected a complete stream for empty input")
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil || len(got) != 0 {
+			t.Fatalf("Expected valid empty stream, got %d bytes, err %v", len(got), err)
+		}
+		if _, err := io.ReadAll(m.Reader(bytes.NewReader(nil))); err == nil {
+			t.Fatal("Expected zero-byte input to be rejected")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"math"
+	"unicode/utf8"
+)
+
+// shannonEntropy estimates the entropy of p in bits per byte (0-8)
+func shannonEntropy(p []byte) float64 {
+	if len(p) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, b := range p {
+		counts[b]++
+	}
+	var entropy float64
+	n := float64(len(p))
+	for _, c := range counts {
+		if c > 0 {
+			f := float64(c) / n
+			entropy -= f * math.Log2(f)
+		}
+	}
+	return entropy
+}
+
+// compressedMagics are signatures of common formats that are already compressed
+var compressedMagics = [][]byte{
+	{0x1f, 0x8b},               // gzip
+	{'P', 'K', 0x03, 0x04},     // zip
+	{0x89, 'P', 'N', 'G'},      // png
+	{0xff, 0xd8, 0xff},         // jpeg
+	{'G', 'I', 'F', '8'},       // gif
+	{'B', 'Z', 'h'},            // bzip2
+	{0x28, 0xb5, 0x2f, 0xfd},   // zstd
+	{0xfd, '7', 'z', 'X', 'Z'}, // xz
+	{'7', 'z', 0xbc, 0xaf},     // 7z
+	{0x04, 0x22, 0x4d, 0x18},   // lz4
+}
+
+// isCompressed reports whether p looks like already compressed data
+func isCompressed(p []byte) bool {
+	if _, ok := detectAlgorithm(p); ok {
+		return true
+	}
+	for _, magic := range compressedMagics {
+		if bytes.HasPrefix(p, magic) {
+			return true
+		}
+	}
+	return shannonEntropy(p) > 7.5
+}
+
+// isText reports whether p is predominantly (at least 95%) printable text
+func isText(p []byte) bool {
+	if len(p) == 0 {
+		return false
+	}
+	total, binary := len(p), 0
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		if (r == utf8.RuneError && size == 1) || (r < 0x20 && r != '\n' && r != '\r' && r != '\t') {
+			binary += size
+		}
+		p = p[size:]
+	}
+	return binary*20 <= total
+}
@@ -0,0 +1,122 @@
+package compressionstdlib
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewFromEnv creates a middleware from environment variables named after the
+// JSON keys of Config in upper case, following prefix and an underscore. With
+// prefix "HB_COMPRESSION", HB_COMPRESSION_ALGORITHM selects the algorithm by
+// name and HB_COMPRESSION_LEVEL the level; likewise for
+// HB_COMPRESSION_MAX_DECOMPRESSED_SIZE, HB_COMPRESSION_STALL_TIMEOUT (a
+// duration such as "30s") and the other fields. Unset variables keep their
+// defaults. Malformed values fail with ErrInvalidOption, invalid settings as
+// in Config.Build.
+func NewFromEnv(prefix string) (*Middleware, error) {
+	cfg, err := configFromEnv(prefix, os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Build()
+}
+
+// configFromEnv reads a Config from the variables returned by lookup
+func configFromEnv(prefix string, lookup func(string) (string, bool)) (Config, error) {
+	e := &envReader{prefix: strings.TrimSuffix(prefix, "_") + "_", lookup: lookup}
+	var cfg Config
+	e.text("ALGORITHM", &cfg.Algorithm)
+	e.optionalInt("LEVEL", &cfg.Level)
+	e.bool("STRICT_LEVEL", &cfg.StrictLevel)
+	e.text("BACKEND", &cfg.Backend)
+	if v, ok := e.get("DICTIONARY_NAME"); ok {
+		cfg.DictionaryName = v
+	}
+	e.bool("STREAM_HEADER", &cfg.StreamHeader)
+	e.bool("SEEKABLE_FORMAT", &cfg.SeekableFormat)
+	e.int("PARALLEL", &cfg.Parallel)
+	e.bool("DETERMINISTIC", &cfg.Deterministic)
+	e.bool("ORIGINAL_SIZE", &cfg.OriginalSize)
+	e.int64("MAX_DECOMPRESSED_SIZE", &cfg.MaxDecompressedSize)
+	e.int64("MAX_COMPRESSED_INPUT", &cfg.MaxCompressedInput)
+	e.bool("STRICT_TRAILER", &cfg.StrictTrailer)
+	e.int("MAX_CONCURRENT_STREAMS", &cfg.MaxConcurrentStreams)
+	e.duration("STALL_TIMEOUT", &cfg.StallTimeout)
+	e.bool("CLOSE_UNDERLYING", &cfg.CloseUnderlying)
+	e.int("FLUSH_EVERY", &cfg.FlushEvery)
+	e.int("READ_BUFFER_SIZE", &cfg.ReadBufferSize)
+	e.int("WRITE_BUFFER_SIZE", &cfg.WriteBufferSize)
+	return cfg, e.err
+}
+
+// envReader parses environment variables, keeping the first error
+type envReader struct {
+	prefix string
+	lookup func(string) (string, bool)
+	err    error
+}
+
+// get returns the trimmed value of the variable name, if set and not empty
+func (e *envReader) get(name string) (string, bool) {
+	v, ok := e.lookup(e.prefix + name)
+	v = strings.TrimSpace(v)
+	return v, ok && v != ""
+}
+
+// parse parses the variable name with fn, recording a failure
+func (e *envReader) parse(name string, fn func(v string) error) {
+	v, ok := e.get(name)
+	if !ok || e.err != nil {
+		return
+	}
+	if err := fn(v); err != nil {
+		e.err = fmt.Errorf("%w: %s%s: %v", ErrInvalidOption, e.prefix, name, err)
+	}
+}
+
+func (e *envReader) text(name string, dst encoding.TextUnmarshaler) {
+	e.parse(name, func(v string) error { return dst.UnmarshalText([]byte(v)) })
+}
+
+func (e *envReader) int(name string, dst *int) {
+	e.parse(name, func(v string) (err error) {
+		*dst, err = strconv.Atoi(v)
+		return err
+	})
+}
+
+// optionalInt sets dst only if the variable is set, so that zero can be told apart from unset
+func (e *envReader) optionalInt(name string, dst **int) {
+	e.parse(name, func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err == nil {
+			*dst = &n
+		}
+		return err
+	})
+}
+
+func (e *envReader) int64(name string, dst *int64) {
+	e.parse(name, func(v string) (err error) {
+		*dst, err = strconv.ParseInt(v, 10, 64)
+		return err
+	})
+}
+
+func (e *envReader) bool(name string, dst *bool) {
+	e.parse(name, func(v string) (err error) {
+		*dst, err = strconv.ParseBool(v)
+		return err
+	})
+}
+
+func (e *envReader) duration(name string, dst *time.Duration) {
+	e.parse(name, func(v string) (err error) {
+		*dst, err = time.ParseDuration(v)
+		return err
+	})
+}
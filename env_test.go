@@ -0,0 +1,63 @@
+package compressionstdlib
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("HB_COMPRESSION_ALGORITHM", "zlib")
+	t.Setenv("HB_COMPRESSION_LEVEL", "9")
+	t.Setenv("HB_COMPRESSION_STRICT_TRAILER", "true")
+	t.Setenv("HB_COMPRESSION_STALL_TIMEOUT", "30s")
+	t.Setenv("HB_COMPRESSION_MAX_DECOMPRESSED_SIZE", "1048576")
+
+	m, err := NewFromEnv("HB_COMPRESSION")
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+	if m.algorithm != Zlib || m.level != 9 || !m.strictTrailer || m.stallTimeout != 30*time.Second || m.maxDecompressed != 1<<20 {
+		t.Fatalf("Unexpected middleware %+v", m)
+	}
+
+	// A trailing underscore in the prefix is optional, unset variables keep their defaults
+	m, err = NewFromEnv("HB_UNSET_")
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+	if m.algorithm != Gzip || m.level != 6 {
+		t.Fatalf("Expected the defaults, got %+v", m)
+	}
+
+	// Level 0 stores data uncompressed instead of selecting the default
+	t.Setenv("HB_STORED_LEVEL", "0")
+	m, err = NewFromEnv("HB_STORED")
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+	if m.level != NoCompression {
+		t.Fatalf("Expected level 0, got %d", m.level)
+	}
+}
+
+func TestNewFromEnvInvalid(t *testing.T) {
+	for name, value := range map[string]string{
+		"HB_ALGORITHM":     "lzma",
+		"HB_LEVEL":         "fast",
+		"HB_STALL_TIMEOUT": "soon",
+		"HB_PARALLEL":      "many",
+	} {
+		lookup := func(key string) (string, bool) {
+			return value, key == name
+		}
+		if _, err := configFromEnv("HB", lookup); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("Expected ErrInvalidOption for %s=%s, got %v", name, value, err)
+		}
+	}
+
+	t.Setenv("HB_LEVEL", "12")
+	if _, err := NewFromEnv("HB"); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("Expected ErrInvalidLevel, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package compressionstdlib
+
+import (
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrHeaderTooLarge is returned when a gzip header field exceeds the configured limit
+var ErrHeaderTooLarge = errors.New("gzip header field exceeds configured limit")
+
+// ErrNestingTooDeep is returned when nested compressed layers exceed the configured depth
+var ErrNestingTooDeep = errors.New("compressed data nested too deeply")
+
+// ErrUnknownFormat is returned when a stream does not match any supported compression format
+var ErrUnknownFormat = errors.New("unknown compression format")
+
+// ErrReadOnlyAlgorithm is reported by writers of algorithms that can only be decompressed
+var ErrReadOnlyAlgorithm = errors.New("compression algorithm is read-only")
+
+// ErrWriterClosed is returned by Write and Flush after the compressing writer was closed
+var ErrWriterClosed = errors.New("compression writer is closed")
+
+// ErrUnsupportedAlgorithm is returned for algorithms that are unknown or not compiled in
+var ErrUnsupportedAlgorithm = errors.New("unsupported compression algorithm")
+
+// ErrCorruptedStream wraps decompression errors caused by malformed or damaged data
+var ErrCorruptedStream = errors.New("corrupted compressed stream")
+
+// ErrTruncatedStream wraps decompression errors caused by data ending before
+// the stream is complete. Such errors also match io.ErrUnexpectedEOF.
+var ErrTruncatedStream = errors.New("truncated compressed stream")
+
+// ErrInvalidLevel is returned for compression levels the algorithm does not support
+var ErrInvalidLevel = errors.New("invalid compression level")
+
+// classify wraps decompression errors with ErrCorruptedStream or
+// ErrTruncatedStream, keeping the original error in the chain
+func classify(err error) error {
+	var corrupt flate.CorruptInputError
+	var structural bzip2.StructuralError
+	switch {
+	case err == nil, err == io.EOF:
+		return err
+	case errors.Is(err, ErrCorruptedStream), errors.Is(err, ErrTruncatedStream), errors.Is(err, ErrUnsupportedFormat):
+		return err
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: %w", ErrTruncatedStream, err)
+	case errors.Is(err, gzip.ErrHeader), errors.Is(err, gzip.ErrChecksum),
+		errors.Is(err, zlib.ErrHeader), errors.Is(err, zlib.ErrChecksum), errors.Is(err, zlib.ErrDictionary),
+		errors.Is(err, ErrInvalidStreamHeader), errors.Is(err, ErrTrailingData), errors.As(err, &corrupt), errors.As(err, &structural):
+		return fmt.Errorf("%w: %w", ErrCorruptedStream, err)
+	}
+	return err
+}
+
+// CompressionError describes a failed stream operation. It wraps the cause,
+// so errors.Is and errors.As see through it.
+type CompressionError struct {
+	// Op is the failed operation: "open", "write", "flush", "read" or "close"
+	Op string
+	// Algorithm is the algorithm the middleware is configured with
+	Algorithm Algorithm
+	// Offset is the position in the compressed stream at which the error was
+	// detected. Readers count the bytes consumed from the source, which may
+	// lie a few KB past damaged data because of read-ahead buffering.
+	Offset int64
+	// DecompressedOffset is the position in the uncompressed data
+	DecompressedOffset int64
+	// Err is the underlying error
+	Err error
+}
+
+func (e *CompressionError) Error() string {
+	return fmt.Sprintf("%v %s at compressed offset %d, decompressed offset %d: %v",
+		e.Algorithm, e.Op, e.Offset, e.DecompressedOffset, e.Err)
+}
+
+func (e *CompressionError) Unwrap() error {
+	return e.Err
+}
+
+// streamError wraps err into a CompressionError for op at the given offsets
+func (m *Middleware) streamError(op string, err error, offset, decompressed int64) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return &CompressionError{Op: op, Algorithm: m.algorithm, Offset: offset, DecompressedOffset: decompressed, Err: err}
+}
+
+// classifiedReader classifies the errors of a decompressing reader and
+// annotates them with the stream offsets
+type classifiedReader struct {
+	r   io.Reader
+	m   *Middleware
+	src *countingReader // compressed input
+	n   int64           // decompressed bytes returned
+}
+
+func (r *classifiedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, r.m.streamError("read", classify(err), r.src.n, r.n)
+}
+
+func (r *classifiedReader) Close() error {
+	if closer, ok := r.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// errReader is returned by Reader when the stream is rejected before decompression starts
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (n int, err error) {
+	return 0, r.err
+}
+
+// errWriter is returned by Writer when the stream cannot be compressed
+type errWriter struct {
+	err error
+}
+
+func (w *errWriter) Write(p []byte) (n int, err error) {
+	return 0, w.err
+}
+
+func (w *errWriter) Close() error {
+	return w.err
+}
@@ -0,0 +1,133 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSentinelErrors_Decompression(t *testing.T) {
+	data := bytes.Repeat([]byte("sentinel errors "), 200)
+
+	for _, alg := range []Algorithm{Gzip, Zlib, Flate} {
+		m := New(alg)
+		compressed := compressWith(t, m, data)
+
+		_, err := io.ReadAll(m.Reader(bytes.NewReader(compressed[:len(compressed)/2])))
+		if !errors.Is(err, ErrTruncatedStream) {
+			t.Fatalf("%v: Expected ErrTruncatedStream, got %v", alg, err)
+		}
+
+		corrupted := append([]byte{}, compressed...)
+		corrupted[len(corrupted)-1] ^= 0xff
+		if alg == Flate {
+			corrupted[len(corrupted)/2] ^= 0xff
+		}
+		_, err = io.ReadAll(m.Reader(bytes.NewReader(corrupted)))
+		if !errors.Is(err, ErrCorruptedStream) {
+			t.Fatalf("%v: Expected ErrCorruptedStream, got %v", alg, err)
+		}
+	}
+
+	// The original error stays in the chain
+	_, err := io.ReadAll(New(Gzip).Reader(bytes.NewReader([]byte("not gzip data"))))
+	if !errors.Is(err, ErrCorruptedStream) || !errors.Is(err, gzip.ErrHeader) {
+		t.Fatalf("Expected ErrCorruptedStream wrapping gzip.ErrHeader, got %v", err)
+	}
+}
+
+func TestSentinelErrors_Configuration(t *testing.T) {
+	if _, err := New(Algorithm(99)).NewWriter(&bytes.Buffer{}); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Fatalf("Expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+	if _, err := ParseAlgorithm("unknown"); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Fatalf("Expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+
+	m := New(Zlib)
+	m.level = 42
+	if _, err := m.NewWriter(&bytes.Buffer{}); !errors.Is(err, ErrInvalidLevel) {
+		t.Fatalf("Expected ErrInvalidLevel, got %v", err)
+	}
+}
+
+func TestTruncatedVersusCorrupted(t *testing.T) {
+	data := bytes.Repeat([]byte("incomplete upload or bad data? "), 300)
+	tests := map[string]*Middleware{
+		"gzip":          New(Gzip),
+		"zlib":          New(Zlib),
+		"lzw":           New(LZW),
+		"stream header": New(Gzip, WithStreamHeader()),
+		"lazy":          NewLazy(),
+		"skip checksum": New(Gzip, WithSkipChecksum()),
+	}
+	for name, m := range tests {
+		compressed := compressWith(t, m, data)
+
+		// Every cut, including empty input, is reported as truncated
+		for i := 0; i < len(compressed); i++ {
+			_, err := io.ReadAll(m.Reader(bytes.NewReader(compressed[:i])))
+			if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.Is(err, ErrTruncatedStream) || errors.Is(err, ErrCorruptedStream) {
+				t.Fatalf("%s: Expected truncation at %d of %d, got %v", name, i, len(compressed), err)
+			}
+		}
+	}
+
+	for _, alg := range []Algorithm{Gzip, Zlib} {
+		m := New(alg)
+		corrupted := compressWith(t, m, data)
+		corrupted[len(corrupted)-1] ^= 0xff
+		_, err := io.ReadAll(m.Reader(bytes.NewReader(corrupted)))
+		if !errors.Is(err, ErrCorruptedStream) || errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("%v: Expected checksum failure to be corrupted only, got %v", alg, err)
+		}
+	}
+}
+
+func TestCompressionError_Read(t *testing.T) {
+	data := bytes.Repeat([]byte("correlate failures with storage offsets "), 5000)
+	m := New(Gzip)
+	compressed := compressWith(t, m, data)
+	compressed[len(compressed)-4] ^= 0xff // size trailer
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	var ce *CompressionError
+	if !errors.As(err, &ce) {
+		t.Fatalf("Expected CompressionError, got %v", err)
+	}
+	if ce.Op != "read" || ce.Algorithm != Gzip || ce.Offset != int64(len(compressed)) || ce.DecompressedOffset != int64(len(got)) {
+		t.Fatalf("Unexpected error fields %+v", ce)
+	}
+	if !errors.Is(err, ErrCorruptedStream) || !errors.Is(err, gzip.ErrChecksum) {
+		t.Fatalf("Expected the cause in the chain, got %v", err)
+	}
+	want := fmt.Sprintf("gzip read at compressed offset %d, decompressed offset %d: ", len(compressed), len(got))
+	if !strings.HasPrefix(err.Error(), want) {
+		t.Fatalf("Expected error starting with %q, got %q", want, err)
+	}
+
+	// Errors found while opening the stream are reported as such
+	_, err = m.NewReader(strings.NewReader("definitely not a gzip stream"))
+	if !errors.As(err, &ce) || ce.Op != "open" || !errors.Is(err, gzip.ErrHeader) {
+		t.Fatalf("Expected open CompressionError, got %v", err)
+	}
+}
+
+func TestCompressionError_Write(t *testing.T) {
+	w := New(Flate).Writer(failingWriter{}).(io.WriteCloser)
+	if _, err := w.Write(bytes.Repeat([]byte("x"), 1000)); err != nil {
+		t.Fatalf("Write failed before any output: %v", err)
+	}
+	err := w.Close()
+	var ce *CompressionError
+	if !errors.As(err, &ce) || ce.Op != "close" || ce.Algorithm != Flate || ce.DecompressedOffset != 1000 {
+		t.Fatalf("Expected close CompressionError after 1000 bytes, got %v", err)
+	}
+	if _, err := w.Write([]byte("x")); !errors.As(err, &ce) || ce.Op != "write" || !errors.Is(err, ErrWriterClosed) {
+		t.Fatalf("Expected write CompressionError, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// WithDeltaFilter replaces each byte with its difference to the previous byte
+// before compression and reverses the transform on read. Slowly changing or
+// monotonically increasing numeric data becomes long runs of small values,
+// which compress far better.
+func WithDeltaFilter() Option {
+	return func(m *Middleware) {
+		m.deltaFilter = true
+	}
+}
+
+// deltaWriter delta encodes the data written to the compressor
+type deltaWriter struct {
+	io.Writer
+	prev byte
+	buf  []byte
+}
+
+func (w *deltaWriter) Write(p []byte) (int, error) {
+	if cap(w.buf) < len(p) {
+		w.buf = make([]byte, len(p))
+	}
+	buf := w.buf[:len(p)]
+	for i, b := range p {
+		buf[i] = b - w.prev
+		w.prev = b
+	}
+	return w.Writer.Write(buf)
+}
+
+func (w *deltaWriter) Flush() error {
+	return flush(w.Writer)
+}
+
+func (w *deltaWriter) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// deltaReader reverses the delta encoding of the decompressed data
+type deltaReader struct {
+	io.Reader
+	prev byte
+}
+
+func (r *deltaReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	for i := range p[:n] {
+		p[i] += r.prev
+		r.prev = p[i]
+	}
+	return n, err
+}
+
+func (r *deltaReader) Close() error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// shuffleBlockSize is the amount of data transposed at a time by WithByteShuffle
+const shuffleBlockSize = 64 * 1024
+
+// WithByteShuffle transposes the bytes of fixed-width records before
+// compression, so that the first bytes of all records are stored together,
+// then all second bytes, and so on, and reverses the transform on read. For
+// columns of integers or floats of the given width in bytes, the similar
+// high-order bytes end up next to each other and compress much better. Data is
+// shuffled in blocks of 64KB; a width below 2 disables the filter.
+func WithByteShuffle(width int) Option {
+	return func(m *Middleware) {
+		if width >= 2 {
+			m.shuffleWidth = width
+		} else {
+			m.shuffleWidth = 0
+		}
+	}
+}
+
+// shuffle transposes the complete records of src into dst, copying trailing bytes unchanged
+func shuffle(dst, src []byte, width int) {
+	records := len(src) / width
+	for i := 0; i < records; i++ {
+		for j := 0; j < width; j++ {
+			dst[j*records+i] = src[i*width+j]
+		}
+	}
+	copy(dst[records*width:], src[records*width:])
+}
+
+// unshuffle reverses shuffle
+func unshuffle(dst, src []byte, width int) {
+	records := len(src) / width
+	for i := 0; i < records; i++ {
+		for j := 0; j < width; j++ {
+			dst[i*width+j] = src[j*records+i]
+		}
+	}
+	copy(dst[records*width:], src[records*width:])
+}
+
+// shuffleWriter collects blocks of records and writes them shuffled, each
+// prefixed with its length so that Flush can emit a partial block
+type shuffleWriter struct {
+	io.Writer
+	width int
+	buf   []byte
+	out   []byte
+	err   error
+}
+
+func (w *shuffleWriter) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	size := shuffleBlockSize - shuffleBlockSize%w.width
+	for len(p) > 0 {
+		m := min(len(p), size-len(w.buf))
+		w.buf = append(w.buf, p[:m]...)
+		n += m
+		p = p[m:]
+		if len(w.buf) == size {
+			if err := w.writeBlock(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// writeBlock writes the buffered data as one shuffled block
+func (w *shuffleWriter) writeBlock() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	w.out = binary.AppendUvarint(w.out[:0], uint64(len(w.buf)))
+	header := len(w.out)
+	w.out = append(w.out, w.buf...)
+	shuffle(w.out[header:], w.buf, w.width)
+	w.buf = w.buf[:0]
+	_, w.err = w.Writer.Write(w.out)
+	return w.err
+}
+
+func (w *shuffleWriter) Flush() error {
+	if err := w.writeBlock(); err != nil {
+		return err
+	}
+	return flush(w.Writer)
+}
+
+func (w *shuffleWriter) Close() error {
+	if err := w.writeBlock(); err != nil {
+		return err
+	}
+	if closer, ok := w.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// shuffleReader reverses the byte shuffle of the decompressed blocks
+type shuffleReader struct {
+	r     *bufio.Reader
+	src   io.Reader
+	width int
+	block []byte
+	out   []byte
+	buf   []byte
+	err   error
+}
+
+func newShuffleReader(r io.Reader, width int) *shuffleReader {
+	return &shuffleReader{r: bufio.NewReader(r), src: r, width: width}
+}
+
+func (r *shuffleReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.readBlock()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// readBlock reads and unshuffles the next block
+func (r *shuffleReader) readBlock() error {
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		return noEOF(err)
+	}
+	if size > shuffleBlockSize {
+		return fmt.Errorf("%w: invalid shuffle block size %d", ErrCorruptedStream, size)
+	}
+	if r.block == nil {
+		r.block = make([]byte, shuffleBlockSize)
+		r.out = make([]byte, shuffleBlockSize)
+	}
+	block := r.block[:size]
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		return noEOF(err)
+	}
+	r.buf = r.out[:size]
+	unshuffle(r.buf, block, r.width)
+	return nil
+}
+
+func (r *shuffleReader) Close() error {
+	if closer, ok := r.src.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDeltaFilter(t *testing.T) {
+	// A monotonically increasing sample value with irregular steps
+	rng := rand.New(rand.NewSource(1))
+	data := make([]byte, 50000)
+	var value byte
+	for i := range data {
+		value += byte(1 + rng.Intn(3))
+		data[i] = value
+	}
+
+	plain := compressWith(t, New(Gzip), data)
+	for _, m := range []*Middleware{
+		New(Gzip, WithDeltaFilter()),
+		NewLazy(WithDeltaFilter()),
+	} {
+		filtered := compressWith(t, m, data)
+		if len(filtered) >= len(plain) {
+			t.Fatalf("Expected delta filter to improve ratio, got %d >= %d bytes", len(filtered), len(plain))
+		}
+
+		// Read in small chunks to carry the previous byte across reads
+		var got []byte
+		r := m.Reader(bytes.NewReader(filtered))
+		buf := make([]byte, 7)
+		for {
+			n, err := r.Read(buf)
+			got = append(got, buf[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Failed to read: %v", err)
+			}
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatal("Data mismatch after delta decoding")
+		}
+	}
+}
+
+func TestByteShuffle(t *testing.T) {
+	// Slowly varying float64 column plus a trailing partial record
+	var data []byte
+	for i := 0; i < 20000; i++ {
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(20+float64(i)/1000))
+	}
+	data = append(data, 1, 2, 3)
+
+	plain := compressWith(t, New(Gzip), data)
+	m := New(Gzip, WithByteShuffle(8))
+	shuffled := compressWith(t, m, data)
+	if len(shuffled) >= len(plain) {
+		t.Fatalf("Expected byte shuffle to improve ratio, got %d >= %d bytes", len(shuffled), len(plain))
+	}
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(shuffled)))
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch after unshuffling")
+	}
+}
+
+func TestByteShuffleFlush(t *testing.T) {
+	m := New(Zlib, WithByteShuffle(4), WithDeltaFilter())
+
+	var buf bytes.Buffer
+	w := m.Writer(&buf)
+	if _, err := w.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if err := w.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+
+	// A partial block becomes readable after Flush
+	got := make([]byte, 10)
+	if _, err := io.ReadFull(m.Reader(bytes.NewReader(buf.Bytes())), got); err != nil {
+		t.Fatalf("Failed to read flushed block: %v", err)
+	}
+	if string(got) != "0123456789" {
+		t.Fatalf("Expected %q, got %q", "0123456789", got)
+	}
+
+	if _, err := w.Write([]byte("abcdef")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if err := w.(io.Closer).Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	all, err := io.ReadAll(m.Reader(&buf))
+	if err != nil || string(all) != "0123456789abcdef" {
+		t.Fatalf("Expected both blocks, got %q, err %v", all, err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package fuzztest provides fuzzing helpers for compression middlewares, so
+// optional codec backends can be checked with the same harness as the
+// built-in algorithms.
+package fuzztest
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	compression "schneider.vip/hybridbuffer/middleware/compressionstdlib"
+)
+
+// seeds are the initial corpus of RoundTrip
+var seeds = [][]byte{
+	{},
+	[]byte("a"),
+	bytes.Repeat([]byte("round trip seed "), 64),
+	bytes.Repeat([]byte{0}, 4096),
+	{0x1f, 0x8b, 0x08, 0x00, 0x78, 0x9c, 0xff, 0xfe},
+}
+
+// RoundTrip fuzzes m with arbitrary payloads: everything written through
+// m.Writer must be read back unchanged through m.Reader. Each payload is
+// written in two parts split at a fuzzed position to exercise buffering.
+func RoundTrip(f *testing.F, m *compression.Middleware) {
+	f.Helper()
+	for _, seed := range seeds {
+		f.Add(seed, uint16(len(seed)/2))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, split uint16) {
+		var buf bytes.Buffer
+		w, err := m.NewWriter(&buf)
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+		cut := int(split) % (len(data) + 1)
+		for _, part := range [][]byte{data[:cut], data[cut:]} {
+			if _, err := w.Write(part); err != nil {
+				t.Fatalf("Failed to write: %v", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Failed to close writer: %v", err)
+		}
+
+		got, err := io.ReadAll(m.Reader(&buf))
+		if err != nil {
+			t.Fatalf("Failed to read back %d bytes: %v", len(data), err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Round trip mismatch: wrote %d bytes, read %d", len(data), len(got))
+		}
+	})
+}
+
+// Reader fuzzes m.Reader with arbitrary input, which must only ever fail with
+// an error and never panic. Compressed forms of the seeds are added to the
+// corpus, together with truncated copies.
+func Reader(f *testing.F, m *compression.Middleware) {
+	f.Helper()
+	for _, seed := range seeds {
+		var buf bytes.Buffer
+		if w, err := m.NewWriter(&buf); err == nil {
+			w.Write(seed)
+			w.Close()
+		}
+		f.Add(buf.Bytes())
+		f.Add(buf.Bytes()[:buf.Len()/2])
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := m.Reader(bytes.NewReader(data))
+		io.Copy(io.Discard, io.LimitReader(r, 1<<20))
+		if closer, ok := r.(io.Closer); ok {
+			closer.Close()
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package fuzztest
+
+import (
+	"testing"
+
+	compression "schneider.vip/hybridbuffer/middleware/compressionstdlib"
+)
+
+func FuzzRoundTripGzip(f *testing.F) {
+	RoundTrip(f, compression.New(compression.Gzip))
+}
+
+func FuzzRoundTripZlib(f *testing.F) {
+	RoundTrip(f, compression.New(compression.Zlib, compression.WithLevel(1)))
+}
+
+func FuzzRoundTripFlate(f *testing.F) {
+	RoundTrip(f, compression.New(compression.Flate))
+}
+
+func FuzzRoundTripParallel(f *testing.F) {
+	RoundTrip(f, compression.New(compression.Gzip, compression.WithParallel(2)))
+}
+
+func FuzzRoundTripLazy(f *testing.F) {
+	RoundTrip(f, compression.NewLazy(compression.WithOmitEmptyStream()))
+}
+
+func FuzzRoundTripFilters(f *testing.F) {
+	RoundTrip(f, compression.New(compression.Zlib, compression.WithDeltaFilter(), compression.WithByteShuffle(4)))
+}
+
+func FuzzRoundTripRLE(f *testing.F) {
+	RoundTrip(f, compression.New(compression.RLE))
+}
+
+func FuzzRoundTripLZW(f *testing.F) {
+	RoundTrip(f, compression.New(compression.LZW))
+}
+
+func FuzzReaderStrict(f *testing.F) {
+	Reader(f, compression.New(compression.Gzip, compression.WithStrictTrailer(), compression.WithMaxDecompressedSize(1<<20)))
+}
+
+func FuzzReaderRecovery(f *testing.F) {
+	Reader(f, compression.New(compression.Gzip, compression.WithCorruptionRecovery(func(compression.SkippedRange) {})))
+}
@@ -0,0 +1,89 @@
+package compressionstdlib
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+// WithMaxConcurrentStreams limits the number of compression and decompression
+// streams that may be open at the same time. Writer and Reader block until a
+// slot is free; a slot is released when the stream is closed, and for readers
+// also once the stream has been read to the end. Each deflate compressor holds
+// roughly 1MB of state and each decompressor about 40KB, so n bounds the worst
+// case memory of the middleware. A value <= 0 means no limit.
+func WithMaxConcurrentStreams(n int) Option {
+	return func(m *Middleware) {
+		if n > 0 {
+			m.streams = make(chan struct{}, n)
+		} else {
+			m.streams = nil
+		}
+	}
+}
+
+// acquire blocks until a stream slot is available and returns its release function
+func (m *Middleware) acquire() func() {
+	if m.streams == nil {
+		return nil
+	}
+	m.streams <- struct{}{}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-m.streams })
+	}
+}
+
+// gatedWriter releases its stream slot when closed
+type gatedWriter struct {
+	io.Writer
+	release func()
+}
+
+func (w *gatedWriter) Flush() error {
+	return flush(w.Writer)
+}
+
+func (w *gatedWriter) Close() error {
+	defer w.release()
+	if closer, ok := w.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// gatedReader releases its stream slot when closed or when the stream ends
+type gatedReader struct {
+	io.Reader
+	release func()
+}
+
+func (r *gatedReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	if err != nil {
+		r.release()
+	}
+	return n, err
+}
+
+func (r *gatedReader) Header() (*gzip.Header, bool) {
+	if hr, ok := r.Reader.(HeaderReader); ok {
+		return hr.Header()
+	}
+	return nil, false
+}
+
+func (r *gatedReader) ExpectedSize() (int64, bool) {
+	if sr, ok := r.Reader.(ExpectedSizer); ok {
+		return sr.ExpectedSize()
+	}
+	return 0, false
+}
+
+func (r *gatedReader) Close() error {
+	defer r.release()
+	if closer, ok := r.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
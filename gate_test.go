@@ -0,0 +1,50 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMaxConcurrentStreams(t *testing.T) {
+	m := New(Gzip, WithMaxConcurrentStreams(1))
+
+	var buf bytes.Buffer
+	first := m.Writer(&buf)
+
+	opened := make(chan io.Writer)
+	go func() {
+		opened <- m.Writer(&bytes.Buffer{})
+	}()
+
+	select {
+	case <-opened:
+		t.Fatal("Expected second Writer to block while first stream is open")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	first.(io.Closer).Close()
+
+	select {
+	case second := <-opened:
+		second.(io.Closer).Close()
+	case <-time.After(time.Second):
+		t.Fatal("Expected second Writer to proceed after first stream was closed")
+	}
+}
+
+func TestMaxConcurrentStreams_ReaderReleasesAtEOF(t *testing.T) {
+	m := New(Gzip, WithMaxConcurrentStreams(1))
+	compressed := compressWith(t, m, []byte("gated"))
+
+	for i := 0; i < 3; i++ {
+		data, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("Failed to read stream %d: %v", i, err)
+		}
+		if string(data) != "gated" {
+			t.Fatalf("Expected %q, got %q", "gated", data)
+		}
+	}
+}
@@ -4,4 +4,11 @@ go 1.23.0
 
 toolchain go1.24.0
 
-require schneider.vip/hybridbuffer/middleware v1.0.6
+require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/golang/snappy v1.0.0
+	github.com/klauspost/compress v1.18.0
+	github.com/pierrec/lz4/v4 v4.1.22
+	github.com/ulikunitz/xz v0.5.12
+	schneider.vip/hybridbuffer/middleware v1.0.6
+)
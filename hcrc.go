@@ -0,0 +1,65 @@
+package compressionstdlib
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// gzipFixedHeader is the size of the fixed part of a gzip member header
+const gzipFixedHeader = 10
+
+// WithHeaderCRC sets the FHCRC flag on written gzip streams and appends the
+// header checksum, which some archival tools require. Readers always verify
+// the header checksum when it is present, so header corruption is reported
+// before any data is inflated. Other formats have no header checksum; NewE
+// rejects the option for them.
+func WithHeaderCRC() Option {
+	return func(m *Middleware) {
+		m.headerCRC = true
+	}
+}
+
+// headerCRCWriter adds FHCRC to the gzip member header at the start of the stream.
+// It relies on the compressor writing a header without optional fields.
+type headerCRCWriter struct {
+	w      io.Writer
+	header []byte
+	done   bool
+}
+
+func (h *headerCRCWriter) Write(p []byte) (int, error) {
+	if h.done {
+		return h.w.Write(p)
+	}
+	n := min(len(p), gzipFixedHeader-len(h.header))
+	h.header = append(h.header, p[:n]...)
+	if len(h.header) < gzipFixedHeader {
+		return len(p), nil
+	}
+
+	h.header[3] |= gzipFlagHdrCrc
+	h.header = binary.LittleEndian.AppendUint16(h.header, uint16(crc32.ChecksumIEEE(h.header)))
+	if _, err := h.w.Write(h.header); err != nil {
+		return 0, err
+	}
+	h.done = true
+	if n == len(p) {
+		return n, nil
+	}
+	m, err := h.w.Write(p[n:])
+	return n + m, err
+}
+
+// checkHeaderCRC verifies the FHCRC checksum of a complete gzip member header, if present
+func checkHeaderCRC(hdr []byte) error {
+	if len(hdr) < gzipFixedHeader || hdr[3]&gzipFlagHdrCrc == 0 {
+		return nil
+	}
+	n := len(hdr) - 2
+	if binary.LittleEndian.Uint16(hdr[n:]) != uint16(crc32.ChecksumIEEE(hdr[:n])) {
+		return gzip.ErrHeader
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestHeaderCRC(t *testing.T) {
+	data := bytes.Repeat([]byte("header checksum "), 100)
+
+	for _, m := range []*Middleware{
+		New(Gzip, WithHeaderCRC()),
+		New(Gzip, WithHeaderCRC(), WithTrustedPipeline()),
+	} {
+		compressed := compressWith(t, m, data)
+		if compressed[3]&gzipFlagHdrCrc == 0 {
+			t.Fatal("Expected FHCRC flag to be set")
+		}
+
+		// The standard library verifies FHCRC as well
+		if _, err := gzip.NewReader(bytes.NewReader(compressed)); err != nil {
+			t.Fatalf("Standard gzip reader rejected header: %v", err)
+		}
+
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("Round trip failed: %v", err)
+		}
+
+		// Corrupting the modification time is caught by the header checksum
+		compressed[4] ^= 0xff
+		if _, err := io.ReadAll(m.Reader(bytes.NewReader(compressed))); !errors.Is(err, gzip.ErrHeader) {
+			t.Fatalf("Expected gzip.ErrHeader, got %v", err)
+		}
+		if err := Verify(bytes.NewReader(compressed), VerifyQuick); !errors.Is(err, gzip.ErrHeader) {
+			t.Fatalf("Expected quick verification to fail with gzip.ErrHeader, got %v", err)
+		}
+	}
+}
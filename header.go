@@ -0,0 +1,161 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// gzip header flags (RFC 1952)
+const (
+	gzipFlagHdrCrc  = 1 << 1
+	gzipFlagExtra   = 1 << 2
+	gzipFlagName    = 1 << 3
+	gzipFlagComment = 1 << 4
+)
+
+// Upper bounds imposed by the gzip format and compress/gzip
+const (
+	gzipMaxExtra  = 1<<16 - 1
+	gzipMaxString = 512
+)
+
+// headerLimits caps the size of variable-length gzip header fields
+type headerLimits struct {
+	name    int
+	comment int
+	extra   int
+}
+
+// WithHeaderLimits caps the length of the gzip header name, comment and extra
+// fields accepted by Reader, checking the header of every member of
+// multi-member input. A value <= 0 leaves the corresponding field unchecked.
+func WithHeaderLimits(name, comment, extra int) Option {
+	return func(m *Middleware) {
+		m.headerLimits = &headerLimits{name: name, comment: comment, extra: extra}
+	}
+}
+
+// HeaderReader is implemented by the readers returned by Reader, NewReader,
+// ReadCloser and NewResettableReader. Header returns the parsed header (name,
+// comment, modification time, extra field) of the gzip member being read,
+// parsing the stream header first if nothing was read yet. It reports false
+// for other algorithms and for readers that skip header parsing, such as with
+// WithTrustedPipeline, WithSkipChecksum or WithMaxNestingDepth.
+type HeaderReader interface {
+	Header() (*gzip.Header, bool)
+}
+
+// gzipHeaderOf finds the gzip decoder beneath the reader layers of a stream
+func gzipHeaderOf(r io.Reader) (*gzip.Header, bool) {
+	for {
+		switch v := r.(type) {
+		case *gzip.Reader:
+			return &v.Header, true
+		case *gzipMemberReader:
+			return &v.zr.Header, true
+		case *recoveryReader:
+			if v.zr == nil || !v.open {
+				return nil, false
+			}
+			return &v.zr.Header, true
+		case *classifiedReader:
+			r = v.r
+		case *sizeLimitReader:
+			r = v.r
+		case *trailingReader:
+			r = v.Reader
+		case *deltaReader:
+			r = v.Reader
+		case *shuffleReader:
+			r = v.src
+		default:
+			return nil, false
+		}
+	}
+}
+
+// bufferSize returns a peek buffer size large enough to hold any header accepted by the limits
+func (l headerLimits) bufferSize() int {
+	size := 10 + 2 // fixed header + CRC16
+	size += 2 + capLimit(l.extra, gzipMaxExtra)
+	size += 1 + capLimit(l.name, gzipMaxString)
+	size += 1 + capLimit(l.comment, gzipMaxString)
+	return size
+}
+
+func capLimit(limit, max int) int {
+	if limit <= 0 || limit > max {
+		return max
+	}
+	return limit
+}
+
+// parseGzipHeader inspects the gzip header at the start of br without consuming it
+// and returns its length. Malformed or truncated headers yield a zero length and no
+// error so that compress/gzip can report them; only limit violations are returned.
+func parseGzipHeader(br *bufio.Reader, limits headerLimits) (int, error) {
+	fixed, err := br.Peek(10)
+	if err != nil || fixed[0] != 0x1f || fixed[1] != 0x8b {
+		return 0, nil
+	}
+	flags := fixed[3]
+	n := 10
+
+	if flags&gzipFlagExtra != 0 {
+		p, err := br.Peek(n + 2)
+		if err != nil {
+			return 0, nil
+		}
+		xlen := int(p[n]) | int(p[n+1])<<8
+		if limits.extra > 0 && xlen > limits.extra {
+			return 0, fmt.Errorf("%w: extra field is %d bytes, limit %d", ErrHeaderTooLarge, xlen, limits.extra)
+		}
+		n += 2 + xlen
+	}
+
+	if flags&gzipFlagName != 0 {
+		if n, err = skipZeroTerminated(br, n, capLimit(limits.name, gzipMaxString), "name"); n == 0 || err != nil {
+			return 0, err
+		}
+	}
+
+	if flags&gzipFlagComment != 0 {
+		if n, err = skipZeroTerminated(br, n, capLimit(limits.comment, gzipMaxString), "comment"); n == 0 || err != nil {
+			return 0, err
+		}
+	}
+
+	if flags&gzipFlagHdrCrc != 0 {
+		n += 2
+	}
+
+	return n, nil
+}
+
+// gzipHeaderTruncated reports whether the remaining input of br is the
+// beginning of a gzip header that ends before the header is complete
+func gzipHeaderTruncated(br *bufio.Reader) bool {
+	p, err := br.Peek(br.Size())
+	if err == nil {
+		return false
+	}
+	return bytes.HasPrefix([]byte{0x1f, 0x8b}, p[:min(len(p), 2)])
+}
+
+// skipZeroTerminated returns the offset just past the zero-terminated string starting at off
+func skipZeroTerminated(br *bufio.Reader, off, limit int, field string) (int, error) {
+	p, _ := br.Peek(off + limit + 1)
+	if len(p) <= off {
+		return 0, nil
+	}
+	if i := bytes.IndexByte(p[off:], 0); i >= 0 {
+		return off + i + 1, nil
+	}
+	if len(p)-off > limit {
+		return 0, fmt.Errorf("%w: %s field exceeds %d bytes", ErrHeaderTooLarge, field, limit)
+	}
+	return 0, nil
+}
@@ -0,0 +1,119 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gzipWithHeader(t *testing.T, hdr gzip.Header, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Header = hdr
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("Failed to write gzip data: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestHeaderLimits_WithinLimits(t *testing.T) {
+	data := []byte("header limits payload")
+	compressed := gzipWithHeader(t, gzip.Header{Name: "file.txt", Comment: "ok", Extra: []byte("ab")}, data)
+
+	m := New(Gzip, WithHeaderLimits(64, 64, 64))
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to read within limits: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Expected %q, got %q", data, got)
+	}
+}
+
+func TestHeaderLimits_Exceeded(t *testing.T) {
+	tests := []struct {
+		name string
+		hdr  gzip.Header
+	}{
+		{"name", gzip.Header{Name: strings.Repeat("n", 100)}},
+		{"comment", gzip.Header{Comment: strings.Repeat("c", 100)}},
+		{"extra", gzip.Header{Extra: bytes.Repeat([]byte("e"), 100)}},
+	}
+
+	m := New(Gzip, WithHeaderLimits(16, 16, 16))
+	for _, tt := range tests {
+		compressed := gzipWithHeader(t, tt.hdr, []byte("payload"))
+		_, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if !errors.Is(err, ErrHeaderTooLarge) {
+			t.Fatalf("%s: Expected ErrHeaderTooLarge, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestHeaderReader(t *testing.T) {
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := gzip.Header{Name: "spill.bin", Comment: "segment 7", ModTime: modTime, Extra: []byte("xt")}
+	compressed := gzipWithHeader(t, want, []byte("payload"))
+
+	for _, m := range []*Middleware{New(Gzip), New(Gzip, WithMaxConcurrentStreams(1)), New(Gzip, WithStrictTrailer())} {
+		r := m.ReadCloser(bytes.NewReader(compressed))
+
+		// The header is available before the first Read
+		hdr, ok := r.(HeaderReader).Header()
+		if !ok {
+			t.Fatal("Expected a gzip header")
+		}
+		if hdr.Name != want.Name || hdr.Comment != want.Comment || !hdr.ModTime.Equal(modTime) || !bytes.Equal(hdr.Extra, want.Extra) {
+			t.Fatalf("Unexpected header %+v", hdr)
+		}
+		if got, err := io.ReadAll(r); err != nil || string(got) != "payload" {
+			t.Fatalf("Failed to read: %v", err)
+		}
+		r.Close()
+	}
+
+	rr, err := New(Gzip).NewResettableReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("Failed to open: %v", err)
+	}
+	if hdr, ok := rr.Header(); !ok || hdr.Name != want.Name {
+		t.Fatalf("Unexpected resettable reader header %+v", hdr)
+	}
+
+	if _, ok := New(Zlib).Reader(bytes.NewReader(nil)).(HeaderReader).Header(); ok {
+		t.Fatal("Expected no gzip header for zlib streams")
+	}
+}
+
+func TestHeaderLimits_LaterMember(t *testing.T) {
+	first := gzipWithHeader(t, gzip.Header{Name: "ok"}, []byte("first"))
+	second := gzipWithHeader(t, gzip.Header{Extra: bytes.Repeat([]byte("e"), 100)}, []byte("second"))
+	data := append(append([]byte{}, first...), second...)
+
+	for name, opts := range map[string][]Option{
+		"default":  nil,
+		"strict":   {WithStrictTrailer()},
+		"trusted":  {WithTrustedPipeline()},
+		"recovery": {WithCorruptionRecovery(func(SkippedRange) {})},
+	} {
+		m := New(Gzip, append(opts, WithHeaderLimits(16, 16, 16))...)
+		_, err := io.ReadAll(m.Reader(bytes.NewReader(data)))
+		if !errors.Is(err, ErrHeaderTooLarge) {
+			t.Fatalf("%s: Expected ErrHeaderTooLarge for the second member, got %v", name, err)
+		}
+
+		// Both members are read within the limits
+		got, err := io.ReadAll(New(Gzip, append(opts, WithHeaderLimits(0, 0, 128))...).Reader(bytes.NewReader(data)))
+		if err != nil || string(got) != "firstsecond" {
+			t.Fatalf("%s: Expected both members, got %q, %v", name, got, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package compressionstdlib
+
+import "io"
+
+// WithErrorHandler registers fn to be called whenever a stream of the
+// middleware fails, so logging and metrics can be centralized. op names the
+// failing operation: "open" (NewWriter, NewReader), "write", "flush", "read"
+// or "close". A reader reports a sticky error only once; io.EOF is not an
+// error.
+func WithErrorHandler(fn func(op string, err error)) Option {
+	return func(m *Middleware) {
+		m.onError = fn
+	}
+}
+
+// report passes err to the error handler, if any, and returns it
+func (m *Middleware) report(op string, err error) error {
+	if err != nil && err != io.EOF && m.onError != nil {
+		m.onError(op, err)
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	type report struct {
+		op  string
+		err error
+	}
+	var reports []report
+	m := New(Gzip, WithErrorHandler(func(op string, err error) {
+		reports = append(reports, report{op, err})
+	}))
+
+	// Successful streams report nothing
+	data := []byte("no errors here")
+	if got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data)))); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Round trip failed: %v", err)
+	}
+	if len(reports) != 0 {
+		t.Fatalf("Expected no reports, got %+v", reports)
+	}
+
+	r := m.Reader(bytes.NewReader([]byte("this is not gzip data")))
+	r.Read(make([]byte, 10))
+	r.Read(make([]byte, 10))
+	if len(reports) != 1 || reports[0].op != "read" || !errors.Is(reports[0].err, ErrCorruptedStream) {
+		t.Fatalf("Expected a single read report, got %+v", reports)
+	}
+
+	reports = nil
+	w := m.Writer(failingWriter{}).(io.WriteCloser)
+	w.Write(make([]byte, 100))
+	w.Close()
+	if len(reports) == 0 || reports[len(reports)-1].op != "close" {
+		t.Fatalf("Expected close report, got %+v", reports)
+	}
+
+	reports = nil
+	if _, err := New(Algorithm(99), WithErrorHandler(func(op string, err error) {
+		reports = append(reports, report{op, err})
+	})).NewWriter(&bytes.Buffer{}); err == nil {
+		t.Fatal("Expected error for unsupported algorithm")
+	}
+	if len(reports) != 1 || reports[0].op != "open" {
+		t.Fatalf("Expected open report, got %+v", reports)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build klauspost
+
+package compressionstdlib
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+)
+
+func init() {
+	registerBackend(BackendKlauspost, Gzip, codec{
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		},
+	})
+	registerBackend(BackendKlauspost, Zlib, codec{
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return zlib.NewWriterLevel(w, level)
+		},
+	})
+	registerBackend(BackendKlauspost, Flate, codec{
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		},
+	})
+}
@@ -0,0 +1,33 @@
+//go:build klauspost
+
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestKlauspostBackend(t *testing.T) {
+	data := bytes.Repeat([]byte("alternative deflate backend "), 5000)
+	for _, algorithm := range []Algorithm{Gzip, Zlib, Flate} {
+		for _, level := range []int{NoCompression, BestSpeed, 6, BestCompression} {
+			m, err := NewE(algorithm, WithBackend(BackendKlauspost), WithLevel(level))
+			if err != nil {
+				t.Fatalf("%v level %d: %v", algorithm, level, err)
+			}
+			if _, ok := m.backendWriter(io.Discard); !ok {
+				t.Fatalf("%v level %d: expected the klauspost backend to be used", algorithm, level)
+			}
+			compressed := compressWith(t, m, data)
+			// Streams are decoded by the standard library
+			got, err := io.ReadAll(New(algorithm).Reader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Fatalf("%v level %d: read failed: %v", algorithm, level, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%v level %d: data mismatch", algorithm, level)
+			}
+		}
+	}
+}
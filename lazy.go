@@ -0,0 +1,143 @@
+package compressionstdlib
+
+import (
+	"compress/flate"
+	"io"
+)
+
+// lazySniffSize is the amount of data buffered before the lazy writer commits to an algorithm
+const lazySniffSize = 4 * 1024
+
+// NewLazy creates a middleware that picks the algorithm and level per stream.
+// The Writer buffers the first few KB written to it and then commits: text is
+// compressed for best ratio, other binary data for speed, and data that is
+// already compressed is stored without deflate effort. The choice is recorded
+// in a small stream header, so the Reader decodes any stream automatically.
+func NewLazy(opts ...Option) *Middleware {
+	m := New(Gzip, opts...)
+	m.selector = classifySample
+	return m
+}
+
+// classifySample selects algorithm and level for a stream from its first bytes
+func classifySample(_ *Middleware, sample []byte) (Algorithm, int) {
+	switch {
+	case isCompressed(sample):
+		return Gzip, flate.NoCompression
+	case isText(sample):
+		return Gzip, flate.BestCompression
+	default:
+		return Gzip, flate.BestSpeed
+	}
+}
+
+// derive returns a copy of the middleware committed to the given algorithm and level
+func (m *Middleware) derive(algorithm Algorithm, level int) *Middleware {
+	d := *m
+	d.algorithm = algorithm
+	d.level = level
+	d.selector = nil
+	d.autoLevel = false
+	d.dictName = ""
+	d.streamHeader = false
+	return &d
+}
+
+// lazyWriter buffers the beginning of a stream until the algorithm is selected
+type lazyWriter struct {
+	m      *Middleware
+	w      io.Writer
+	choose func(sample []byte) (Algorithm, int)
+	tagged bool // write a stream header recording the choice
+	buf    []byte
+	cw     io.Writer
+	err    error
+}
+
+func (w *lazyWriter) Write(p []byte) (n int, err error) {
+	if w.cw == nil {
+		limit := w.m.sampleLimit()
+		n = min(len(p), limit-len(w.buf))
+		w.buf = append(w.buf, p[:n]...)
+		if len(w.buf) < limit {
+			return n, nil
+		}
+		if err := w.commit(); err != nil {
+			return n, err
+		}
+		p = p[n:]
+	}
+	m, err := w.cw.Write(p)
+	return n + m, err
+}
+
+// commit selects the algorithm, writes the stream header and the buffered data
+func (w *lazyWriter) commit() error {
+	if w.cw != nil || w.err != nil {
+		return w.err
+	}
+	algorithm, level := w.choose(w.buf)
+	d := w.m.derive(algorithm, level)
+	if w.m.dictName != "" {
+		d.dictionary = w.m.namedDictionary()
+	}
+	if w.tagged {
+		h := streamHeader{algorithm: algorithm, level: level, dictionary: w.m.dictName}
+		if _, w.err = w.w.Write(marshalStreamHeader(h)); w.err != nil {
+			return w.err
+		}
+	}
+	w.cw = d.writer(w.w)
+	_, w.err = w.cw.Write(w.buf)
+	w.buf = nil
+	return w.err
+}
+
+func (w *lazyWriter) Flush() error {
+	if err := w.commit(); err != nil {
+		return err
+	}
+	return flush(w.cw)
+}
+
+func (w *lazyWriter) Close() error {
+	if err := w.commit(); err != nil {
+		return err
+	}
+	if closer, ok := w.cw.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// WithAutoLevel chooses the deflate level per stream from an entropy estimate of
+// the first few KB written, so a single middleware gives a good CPU/ratio
+// tradeoff across heterogeneous payloads. Any level set with WithLevel is ignored.
+// With WithStreamHeader or WithDictionaryName the header records the chosen level.
+func WithAutoLevel() Option {
+	return func(m *Middleware) {
+		m.autoLevel = true
+	}
+}
+
+// chooseLevel keeps the configured algorithm and picks the level from the sample entropy
+func (m *Middleware) chooseLevel(sample []byte) (Algorithm, int) {
+	return m.algorithm, entropyLevel(shannonEntropy(sample))
+}
+
+// entropyLevel maps an entropy estimate in bits per byte to a deflate level
+func entropyLevel(entropy float64) int {
+	switch {
+	case entropy < 2:
+		// Highly redundant data reaches nearly the best ratio at the fastest level
+		return flate.BestSpeed
+	case entropy < 5.5:
+		// Text and structured data benefit most from a deeper match search
+		return flate.BestCompression
+	case entropy < 7.5:
+		return 5
+	default:
+		// Close to random, deflate effort buys almost nothing
+		return flate.BestSpeed
+	}
+}
@@ -0,0 +1,107 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestLazySelection(t *testing.T) {
+	noise := make([]byte, 20000)
+	rand.New(rand.NewSource(1)).Read(noise)
+	binary := make([]byte, 20000)
+	for i := range binary {
+		binary[i] = byte(i % 7)
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		level int
+	}{
+		{"text", bytes.Repeat([]byte("plain text line\n"), 2000), flate.BestCompression},
+		{"binary", binary, flate.BestSpeed},
+		{"compressed", noise, flate.NoCompression},
+		{"short", []byte("short text"), flate.BestCompression},
+		{"empty", nil, flate.BestSpeed},
+	}
+
+	m := NewLazy()
+	for _, tt := range tests {
+		compressed := compressWith(t, m, tt.data)
+		if len(compressed) < streamHeaderSize {
+			t.Fatalf("%s: Missing stream header", tt.name)
+		}
+		if level := int(int8(compressed[6])); level != tt.level {
+			t.Fatalf("%s: Expected level %d, got %d", tt.name, tt.level, level)
+		}
+
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%s: Failed to read: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, tt.data) {
+			t.Fatalf("%s: Data mismatch", tt.name)
+		}
+	}
+}
+
+func TestLazyInvalidHeader(t *testing.T) {
+	compressed := compressWith(t, New(Gzip), []byte("no stream header"))
+	_, err := io.ReadAll(NewLazy().Reader(bytes.NewReader(compressed)))
+	if err == nil {
+		t.Fatal("Expected error for stream without header")
+	}
+}
+
+func TestAutoLevel(t *testing.T) {
+	noise := make([]byte, 10000)
+	rand.New(rand.NewSource(2)).Read(noise)
+	text := bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog. "), 500)
+
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		m := New(algorithm, WithAutoLevel())
+		for _, data := range [][]byte{noise, text, bytes.Repeat([]byte{0}, 10000), nil} {
+			compressed := compressWith(t, m, data)
+			got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Fatalf("Algorithm %d: Failed to read: %v", algorithm, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("Algorithm %d: Data mismatch", algorithm)
+			}
+		}
+	}
+
+	if level := entropyLevel(shannonEntropy(noise)); level != flate.BestSpeed {
+		t.Fatalf("Expected BestSpeed for random data, got %d", level)
+	}
+	if level := entropyLevel(shannonEntropy(text)); level != flate.BestCompression {
+		t.Fatalf("Expected BestCompression for text, got %d", level)
+	}
+}
+
+func TestAutoLevelWithStreamHeader(t *testing.T) {
+	text := bytes.Repeat([]byte("The quick brown fox jumps over the lazy dog. "), 500)
+	RegisterDictionary("autolevel-test", []byte("The quick brown fox"))
+
+	for name, m := range map[string]*Middleware{
+		"stream header": New(Gzip, WithAutoLevel(), WithStreamHeader()),
+		"dictionary":    New(Zlib, WithAutoLevel(), WithDictionaryName("autolevel-test")),
+	} {
+		compressed := compressWith(t, m, text)
+		h, err := readStreamHeader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("%s: Failed to read stream header: %v", name, err)
+		}
+		if h.level != flate.BestCompression {
+			t.Fatalf("%s: Expected the automatic level %d to be used, got %d", name, flate.BestCompression, h.level)
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil || !bytes.Equal(got, text) {
+			t.Fatalf("%s: Failed to read: %v", name, err)
+		}
+	}
+}
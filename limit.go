@@ -0,0 +1,98 @@
+package compressionstdlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrDecompressedTooLarge is returned when the decompressed output exceeds the configured limit
+var ErrDecompressedTooLarge = errors.New("decompressed data exceeds configured limit")
+
+// ErrCompressedTooLarge is returned when the compressed input exceeds the configured limit
+var ErrCompressedTooLarge = errors.New("compressed input exceeds configured limit")
+
+// WithMaxDecompressedSize limits the decompressed output of each stream to n
+// bytes. Reader returns the first n bytes and then fails with
+// ErrDecompressedTooLarge, protecting hosts that decode untrusted uploads
+// from decompression bombs. Values of zero or below disable the limit.
+func WithMaxDecompressedSize(n int64) Option {
+	return func(m *Middleware) {
+		if n > 0 {
+			m.maxDecompressed = n
+		} else {
+			m.maxDecompressed = 0
+		}
+	}
+}
+
+// WithMaxCompressedInput limits how many bytes Reader pulls from the
+// underlying source to n. Reading more fails with ErrCompressedTooLarge,
+// protecting against endless or hostile upstream readers. Values of zero or
+// below disable the limit.
+func WithMaxCompressedInput(n int64) Option {
+	return func(m *Middleware) {
+		if n > 0 {
+			m.maxCompressed = n
+		} else {
+			m.maxCompressed = 0
+		}
+	}
+}
+
+// limitOutput enforces the decompressed size limit on r
+func (m *Middleware) limitOutput(r io.Reader) io.Reader {
+	if m.maxDecompressed <= 0 {
+		return r
+	}
+	return newSizeLimitReader(r, m.maxDecompressed, ErrDecompressedTooLarge)
+}
+
+// limitInput enforces the compressed input limit on r
+func (m *Middleware) limitInput(r io.Reader) io.Reader {
+	if m.maxCompressed <= 0 {
+		return r
+	}
+	return newSizeLimitReader(r, m.maxCompressed, ErrCompressedTooLarge)
+}
+
+// sizeLimitReader fails with tooLarge once more than limit bytes are read
+type sizeLimitReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+	tooLarge  error
+}
+
+func newSizeLimitReader(r io.Reader, limit int64, tooLarge error) *sizeLimitReader {
+	return &sizeLimitReader{r: r, limit: limit, remaining: limit, tooLarge: tooLarge}
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, r.exceeded()
+	}
+	// Read one byte beyond the limit to detect output exceeding it
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.r.Read(p)
+	if int64(n) > r.remaining {
+		n = int(r.remaining)
+		r.remaining = -1
+		return n, r.exceeded()
+	}
+	r.remaining -= int64(n)
+	return n, err
+}
+
+func (r *sizeLimitReader) exceeded() error {
+	return fmt.Errorf("%w: more than %d bytes", r.tooLarge, r.limit)
+}
+
+func (r *sizeLimitReader) Close() error {
+	if closer, ok := r.r.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestMaxDecompressedSize(t *testing.T) {
+	bomb := compressWith(t, New(Gzip, WithLevel(9)), make([]byte, 10<<20))
+
+	for _, m := range []*Middleware{
+		New(Gzip, WithMaxDecompressedSize(1<<20)),
+		New(Gzip, WithMaxDecompressedSize(1<<20), WithVerifyBeforeRelease()),
+	} {
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(bomb)))
+		if !errors.Is(err, ErrDecompressedTooLarge) {
+			t.Fatalf("Expected ErrDecompressedTooLarge, got %v", err)
+		}
+		if len(got) > 1<<20 {
+			t.Fatalf("Read %d bytes beyond the limit", len(got)-1<<20)
+		}
+	}
+}
+
+func TestMaxDecompressedSize_WithinLimit(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 1000)
+	m := New(Zlib, WithMaxDecompressedSize(int64(len(data))))
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+	if err != nil {
+		t.Fatalf("Failed to read data at the limit: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+// endlessReader produces an infinite stream of zeros
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func TestMaxCompressedInput(t *testing.T) {
+	// Without the limit, reading an endless source never ends
+	m := New(None, WithMaxCompressedInput(1<<20))
+	_, err := io.Copy(io.Discard, m.Reader(endlessReader{}))
+	if !errors.Is(err, ErrCompressedTooLarge) {
+		t.Fatalf("Expected ErrCompressedTooLarge, got %v", err)
+	}
+
+	data := bytes.Repeat([]byte("bounded input "), 500)
+	compressed := compressWith(t, New(Gzip), data)
+	exact := New(Gzip, WithMaxCompressedInput(int64(len(compressed))))
+	got, err := io.ReadAll(exact.Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to read input at the limit: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+
+	short := New(Gzip, WithMaxCompressedInput(int64(len(compressed)-1)))
+	if _, err := io.ReadAll(short.Reader(bytes.NewReader(compressed))); !errors.Is(err, ErrCompressedTooLarge) {
+		t.Fatalf("Expected ErrCompressedTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build lz4
+
+package compressionstdlib
+
+import (
+	"io"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+// lz4Levels maps the levels 1-9 onto the LZ4 compression levels, level 1 being the fast mode
+var lz4Levels = [...]lz4.CompressionLevel{
+	lz4.Fast, lz4.Level2, lz4.Level3, lz4.Level4, lz4.Level5,
+	lz4.Level6, lz4.Level7, lz4.Level8, lz4.Level9,
+}
+
+// lz4Level returns the LZ4 compression level for level. Level 0 (NoCompression)
+// and below, which can arrive through a stream header, select the fast mode;
+// levels above 9 the best one.
+func lz4Level(level int) lz4.CompressionLevel {
+	switch {
+	case level <= 1:
+		return lz4.Fast
+	case level >= len(lz4Levels):
+		return lz4Levels[len(lz4Levels)-1]
+	}
+	return lz4Levels[level-1]
+}
+
+func init() {
+	registerCodec(LZ4, codec{
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			zw := lz4.NewWriter(w)
+			if err := zw.Apply(lz4.CompressionLevelOption(lz4Level(level))); err != nil {
+				return nil, err
+			}
+			return zw, nil
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(lz4.NewReader(r)), nil
+		},
+	})
+}
@@ -0,0 +1,55 @@
+//go:build lz4
+
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+func TestLZ4RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("lz4 backend "), 5000)
+	for _, level := range []int{1, 5, 9} {
+		m, err := NewE(LZ4, WithLevel(level))
+		if err != nil {
+			t.Fatalf("Level %d: %v", level, err)
+		}
+		compressed := compressWith(t, m, data)
+		if !bytes.HasPrefix(compressed, []byte{0x04, 0x22, 0x4d, 0x18}) {
+			t.Fatalf("Level %d: expected lz4 magic, got % x", level, compressed[:4])
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("Level %d: read failed: %v", level, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Level %d: data mismatch", level)
+		}
+	}
+}
+
+func TestLZ4Level(t *testing.T) {
+	for level, want := range map[int]lz4.CompressionLevel{
+		NoCompression: lz4.Fast,
+		HuffmanOnly:   lz4.Fast,
+		1:             lz4.Fast,
+		2:             lz4.Level2,
+		9:             lz4.Level9,
+		12:            lz4.Level9,
+	} {
+		if got := lz4Level(level); got != want {
+			t.Fatalf("Level %d: expected %v, got %v", level, want, got)
+		}
+	}
+
+	// Levels outside 1-9 can arrive through a stream header
+	m := New(LZ4).derive(LZ4, NoCompression)
+	data := []byte("level zero")
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Failed to round-trip level 0: %v", err)
+	}
+}
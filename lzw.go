@@ -0,0 +1,32 @@
+package compressionstdlib
+
+import "compress/lzw"
+
+// WithLZWOrder sets the bit ordering of LZW codes, lzw.LSB (the default, as
+// used by GIF) or lzw.MSB (as used by TIFF and PDF). Other values are ignored.
+func WithLZWOrder(order lzw.Order) Option {
+	return func(m *Middleware) {
+		if order == lzw.LSB || order == lzw.MSB {
+			m.lzwOrder = order
+		}
+	}
+}
+
+// WithLZWLitWidth sets the number of bits used for literal codes, 2-8. It must
+// match the producer of external streams; 8, the default, fits arbitrary
+// bytes. Other values are ignored.
+func WithLZWLitWidth(width int) Option {
+	return func(m *Middleware) {
+		if width >= 2 && width <= 8 {
+			m.lzwLitWidth = width
+		}
+	}
+}
+
+// lzwSettings returns the configured LZW order and literal width
+func (m *Middleware) lzwSettings() (lzw.Order, int) {
+	if m.lzwLitWidth == 0 {
+		return m.lzwOrder, 8
+	}
+	return m.lzwOrder, m.lzwLitWidth
+}
@@ -0,0 +1,51 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/lzw"
+	"io"
+	"testing"
+)
+
+func TestLZWRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("lzw interop "), 500)
+
+	for _, order := range []lzw.Order{lzw.LSB, lzw.MSB} {
+		m := New(LZW, WithLZWOrder(order))
+		compressed := compressWith(t, m, data)
+
+		// Streams are plain compress/lzw output
+		got, err := io.ReadAll(lzw.NewReader(bytes.NewReader(compressed), order, 8))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("Order %d: compress/lzw failed to read output: %v", order, err)
+		}
+		got, err = io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("Order %d: round trip failed: %v", order, err)
+		}
+	}
+}
+
+func TestLZWLitWidth(t *testing.T) {
+	// 7-bit literals fit ASCII text
+	m := New(LZW, WithLZWOrder(lzw.MSB), WithLZWLitWidth(7))
+	data := []byte("seven bit ascii text, seven bit ascii text")
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Round trip failed: %v", err)
+	}
+
+	// Bytes above the literal width are rejected
+	var buf bytes.Buffer
+	w := m.Writer(&buf)
+	if _, err := w.Write([]byte{0xff}); err == nil {
+		t.Fatal("Expected error writing a byte wider than the literal width")
+	}
+}
+
+func TestLZWInvalidSettings(t *testing.T) {
+	m := New(LZW, WithLZWOrder(lzw.Order(7)), WithLZWLitWidth(9))
+	if order, width := m.lzwSettings(); order != lzw.LSB || width != 8 {
+		t.Fatalf("Expected defaults for invalid settings, got order %d width %d", order, width)
+	}
+}
@@ -0,0 +1,54 @@
+package compressionstdlib
+
+import "io"
+
+// MultiReader returns a reader that presents several independently compressed
+// segments as one continuous decompressed stream. Segments are opened lazily,
+// one at a time, so at most one decompressor is active.
+func (m *Middleware) MultiReader(rs ...io.Reader) io.Reader {
+	return &multiReader{m: m, segments: rs}
+}
+
+// multiReader decompresses segments sequentially
+type multiReader struct {
+	m        *Middleware
+	segments []io.Reader
+	current  io.Reader
+}
+
+func (r *multiReader) Read(p []byte) (n int, err error) {
+	for {
+		if r.current == nil {
+			if len(r.segments) == 0 {
+				return 0, io.EOF
+			}
+			r.current = r.m.Reader(r.segments[0])
+			r.segments = r.segments[1:]
+		}
+
+		n, err = r.current.Read(p)
+		if err == io.EOF {
+			r.closeCurrent()
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close releases the active segment decompressor
+func (r *multiReader) Close() error {
+	r.segments = nil
+	return r.closeCurrent()
+}
+
+func (r *multiReader) closeCurrent() error {
+	current := r.current
+	r.current = nil
+	if closer, ok := current.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestMultiReader(t *testing.T) {
+	m := New(Gzip, WithMaxConcurrentStreams(1))
+
+	parts := [][]byte{
+		[]byte("first segment, "),
+		{},
+		bytes.Repeat([]byte("second segment, "), 1000),
+		[]byte("last segment"),
+	}
+
+	var segments []io.Reader
+	for _, part := range parts {
+		segments = append(segments, bytes.NewReader(compressWith(t, m, part)))
+	}
+
+	got, err := io.ReadAll(m.MultiReader(segments...))
+	if err != nil {
+		t.Fatalf("Failed to read segments: %v", err)
+	}
+	if !bytes.Equal(got, bytes.Join(parts, nil)) {
+		t.Fatal("Multi-segment data mismatch")
+	}
+}
@@ -0,0 +1,121 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+)
+
+// WithMultistream controls whether concatenated gzip members and back-to-back
+// zlib streams are read as one logical stream, as with gzip.Reader.Multistream.
+// It is enabled by default; disabling it stops reading at the end of the first
+// member or stream and ignores the data following it, unless WithStrictTrailer
+// reports it. Data following a zlib stream that does not start with a zlib
+// header (such as padding) ends the logical stream. WithSeekableFormat and
+// WithParallel write many members per stream, so NewE rejects disabling it
+// together with them.
+func WithMultistream(enabled bool) Option {
+	return func(m *Middleware) {
+		m.singleStream = !enabled
+	}
+}
+
+// zlibReader opens the zlib stream(s) at the start of r
+func (m *Middleware) zlibReader(r io.Reader) (io.ReadCloser, error) {
+	// zlib reads through a byte reader without buffering ahead, so br is
+	// positioned exactly at the next stream once the current one ends
+	br := bufio.NewReader(r)
+	zr, err := m.zlibStream(br)
+	if err != nil || m.singleStream {
+		return zr, err
+	}
+	return &zlibMultiReader{m: m, br: br, zr: zr}, nil
+}
+
+// zlibMultiReader reads concatenated zlib streams
+type zlibMultiReader struct {
+	m   *Middleware
+	br  *bufio.Reader
+	zr  io.ReadCloser
+	err error
+}
+
+func (z *zlibMultiReader) Read(p []byte) (int, error) {
+	for z.err == nil {
+		n, err := z.zr.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		if !z.next() {
+			return n, io.EOF
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+	return 0, z.err
+}
+
+// next opens the following stream, reporting whether there is one
+func (z *zlibMultiReader) next() bool {
+	hdr, _ := z.br.Peek(2)
+	if len(hdr) < 2 || hdr[0]&0x0f != 8 || (uint16(hdr[0])<<8|uint16(hdr[1]))%31 != 0 {
+		return false
+	}
+	zr, err := z.m.zlibStream(z.br)
+	if err != nil {
+		z.err = err
+		return true
+	}
+	z.zr.Close()
+	z.zr = zr
+	return true
+}
+
+func (z *zlibMultiReader) Close() error {
+	return z.zr.Close()
+}
+
+// gzipMemberReader reads gzip members one at a time, checking each member's
+// header against WithHeaderLimits. In strict mode it stops before data that
+// does not start with a gzip header instead of failing on it, so such data is
+// reported as trailing data.
+type gzipMemberReader struct {
+	m  *Middleware
+	br *bufio.Reader
+	zr *gzip.Reader
+}
+
+func (z *gzipMemberReader) Read(p []byte) (int, error) {
+	for {
+		n, err := z.zr.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		if z.m.singleStream {
+			return n, io.EOF
+		}
+		if _, err := z.br.Peek(1); err != nil {
+			return n, io.EOF
+		}
+		if hdr, _ := z.br.Peek(2); z.m.strictTrailer && (len(hdr) < 2 || hdr[0] != 0x1f || hdr[1] != 0x8b) {
+			return n, io.EOF
+		}
+		if z.m.headerLimits != nil {
+			if _, err := parseGzipHeader(z.br, *z.m.headerLimits); err != nil {
+				return n, err
+			}
+		}
+		if err := z.zr.Reset(z.br); err != nil {
+			return n, err
+		}
+		z.zr.Multistream(false)
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (z *gzipMemberReader) Close() error {
+	return z.zr.Close()
+}
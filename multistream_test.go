@@ -0,0 +1,137 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestZlibMultistream(t *testing.T) {
+	m := New(Zlib)
+	var concatenated []byte
+	concatenated = append(concatenated, compressWith(t, m, []byte("first record\n"))...)
+	concatenated = append(concatenated, compressWith(t, m, []byte("second record\n"))...)
+	concatenated = append(concatenated, compressWith(t, m, nil)...)
+	concatenated = append(concatenated, compressWith(t, m, []byte("third record\n"))...)
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(concatenated)))
+	if err != nil {
+		t.Fatalf("Failed to read concatenated streams: %v", err)
+	}
+	if want := "first record\nsecond record\nthird record\n"; string(got) != want {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+
+	single := New(Zlib, WithMultistream(false))
+	got, err = io.ReadAll(single.Reader(bytes.NewReader(concatenated)))
+	if err != nil {
+		t.Fatalf("Failed to read first stream: %v", err)
+	}
+	if string(got) != "first record\n" {
+		t.Fatalf("Expected only the first stream, got %q", got)
+	}
+}
+
+func TestZlibMultistreamSkipChecksum(t *testing.T) {
+	var concatenated []byte
+	for _, record := range []string{"first record\n", "second record\n", "", "third record\n"} {
+		concatenated = append(concatenated, compressWith(t, New(Zlib), []byte(record))...)
+	}
+
+	for _, opt := range []Option{WithSkipChecksum(), WithTrustedPipeline()} {
+		got, err := io.ReadAll(New(Zlib, opt).Reader(bytes.NewReader(concatenated)))
+		if err != nil {
+			t.Fatalf("Failed to read concatenated streams: %v", err)
+		}
+		if want := "first record\nsecond record\nthird record\n"; string(got) != want {
+			t.Fatalf("Expected %q, got %q", want, got)
+		}
+
+		got, err = io.ReadAll(New(Zlib, opt, WithMultistream(false)).Reader(bytes.NewReader(concatenated)))
+		if err != nil {
+			t.Fatalf("Failed to read first stream: %v", err)
+		}
+		if string(got) != "first record\n" {
+			t.Fatalf("Expected only the first stream, got %q", got)
+		}
+	}
+
+	// Padding after the stream ends it, as without WithSkipChecksum
+	m := New(Zlib, WithPaddingBuckets(1024), WithSkipChecksum())
+	data := bytes.Repeat([]byte("padded "), 10)
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+	if err != nil {
+		t.Fatalf("Failed to read padded stream: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch after reading padded stream")
+	}
+}
+
+func TestZlibMultistreamCorruptSecondStream(t *testing.T) {
+	m := New(Zlib)
+	second := compressWith(t, m, bytes.Repeat([]byte("second "), 100))
+	second[len(second)-1] ^= 0xff // break the Adler-32 checksum
+
+	data := append(compressWith(t, m, []byte("first ")), second...)
+	if _, err := io.ReadAll(m.Reader(bytes.NewReader(data))); err == nil {
+		t.Fatal("Expected checksum error from the second stream")
+	}
+}
+
+func TestZlibMultistreamIgnoresPadding(t *testing.T) {
+	m := New(Zlib, WithPaddingBuckets(1024))
+	data := bytes.Repeat([]byte("padded "), 10)
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+	if err != nil {
+		t.Fatalf("Failed to read padded stream: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch after reading padded stream")
+	}
+}
+
+func TestGzipMultistream(t *testing.T) {
+	m := New(Gzip)
+	concatenated := append(compressWith(t, m, []byte("first member\n")), compressWith(t, m, []byte("second member\n"))...)
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(concatenated)))
+	if err != nil || string(got) != "first member\nsecond member\n" {
+		t.Fatalf("Expected both members, got %q (%v)", got, err)
+	}
+
+	single := []*Middleware{
+		New(Gzip, WithMultistream(false)),
+		New(Gzip, WithMultistream(false), WithTrustedPipeline()),
+		New(Gzip, WithMultistream(false), WithCorruptionRecovery(nil)),
+	}
+	for _, m := range single {
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(concatenated)))
+		if err != nil || string(got) != "first member\n" {
+			t.Fatalf("Expected the first member only, got %q (%v)", got, err)
+		}
+	}
+
+	rr, err := New(Gzip, WithMultistream(false)).NewResettableReader(bytes.NewReader(concatenated))
+	if err != nil {
+		t.Fatalf("Failed to create resettable reader: %v", err)
+	}
+	if got, err := io.ReadAll(rr); err != nil || string(got) != "first member\n" {
+		t.Fatalf("Expected the first member from the resettable reader, got %q (%v)", got, err)
+	}
+
+	strict := New(Gzip, WithMultistream(false), WithStrictTrailer())
+	if _, err := io.ReadAll(strict.Reader(bytes.NewReader(concatenated))); !errors.Is(err, ErrTrailingData) {
+		t.Fatalf("Expected ErrTrailingData, got %v", err)
+	}
+}
+
+func TestMultistreamDisabledWithMultiMemberWriter(t *testing.T) {
+	for _, opt := range []Option{WithSeekableFormat(), WithParallel(2)} {
+		if _, err := NewE(Gzip, opt, WithMultistream(false)); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("Expected ErrInvalidOption, got %v", err)
+		}
+	}
+}
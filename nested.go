@@ -0,0 +1,92 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WithMaxNestingDepth makes Reader detect gzip or zlib streams nested inside the
+// decompressed data and transparently unwrap up to depth additional layers.
+// Data that is nested deeper fails with ErrNestingTooDeep. A depth of 0 disables detection.
+// Zlib layers are only unwrapped when the data after the header test-decodes.
+func WithMaxNestingDepth(depth int) Option {
+	return func(m *Middleware) {
+		if depth >= 0 {
+			m.maxNesting = depth
+		}
+	}
+}
+
+// detectAlgorithm identifies a compressed stream from its leading magic bytes
+func detectAlgorithm(p []byte) (Algorithm, bool) {
+	if len(p) >= 3 && p[0] == 0x1f && p[1] == 0x8b && p[2] == 8 {
+		return Gzip, true
+	}
+	if len(p) >= 2 {
+		cmf, flg := p[0], p[1]
+		// CM=8 (deflate), CINFO<=7, no preset dictionary, valid FCHECK
+		if cmf&0x0f == 8 && cmf>>4 <= 7 && flg&0x20 == 0 && (uint16(cmf)<<8|uint16(flg))%31 == 0 {
+			return Zlib, true
+		}
+	}
+	return 0, false
+}
+
+// zlibConfirmCap bounds the output decoded while confirming a zlib stream
+const zlibConfirmCap = 64 << 10
+
+// zlibConfirmed test-decodes the buffered start of a stream with a zlib
+// header. Two bytes are easily matched by plain text ("HK", "x^", "8O"), so
+// the stream only counts as zlib when the deflate data following the header
+// decodes without error as far as it is buffered.
+func zlibConfirmed(br *bufio.Reader) bool {
+	p, _ := br.Peek(br.Size())
+	if len(p) < 2 {
+		return false
+	}
+	_, err := io.CopyN(io.Discard, flate.NewReader(bytes.NewReader(p[2:])), zlibConfirmCap)
+	var corrupt flate.CorruptInputError
+	return !errors.As(err, &corrupt)
+}
+
+// nestedReader unwraps compressed layers found inside the decompressed stream
+type nestedReader struct {
+	m     *Middleware
+	r     io.Reader
+	ready bool
+}
+
+func (r *nestedReader) Read(p []byte) (n int, err error) {
+	if !r.ready {
+		if err := r.unwrap(); err != nil {
+			r.r = &errReader{err}
+		}
+		r.ready = true
+	}
+	return r.r.Read(p)
+}
+
+// unwrap peeks at the decompressed data and adds a decompression layer for every nested stream
+func (r *nestedReader) unwrap() error {
+	for depth := 0; ; depth++ {
+		br := bufio.NewReader(r.r)
+		r.r = br
+		magic, _ := br.Peek(3)
+		algorithm, ok := detectAlgorithm(magic)
+		if !ok || (algorithm == Zlib && !zlibConfirmed(br)) {
+			return nil
+		}
+		if depth >= r.m.maxNesting {
+			return fmt.Errorf("%w: more than %d nested layers", ErrNestingTooDeep, r.m.maxNesting)
+		}
+		inner, err := r.m.decompressor(algorithm, br)
+		if err != nil {
+			return err
+		}
+		r.r = inner
+	}
+}
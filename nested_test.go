@@ -0,0 +1,88 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"schneider.vip/hybridbuffer/middleware"
+)
+
+func compressWith(t *testing.T, m middleware.Middleware, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := m.Writer(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Failed to write compressed data: %v", err)
+	}
+	if closer, ok := w.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			t.Fatalf("Failed to close compressor: %v", err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestNestedUnwrap(t *testing.T) {
+	data := []byte("double wrapped legacy payload")
+	inner := compressWith(t, New(Zlib), data)
+	outer := compressWith(t, New(Gzip), inner)
+
+	// Without nesting detection the inner stream is returned as-is
+	got, err := io.ReadAll(New(Gzip).Reader(bytes.NewReader(outer)))
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, inner) {
+		t.Fatal("Expected inner compressed stream without nesting detection")
+	}
+
+	got, err = io.ReadAll(New(Gzip, WithMaxNestingDepth(1)).Reader(bytes.NewReader(outer)))
+	if err != nil {
+		t.Fatalf("Failed to unwrap nested stream: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("Expected %q, got %q", data, got)
+	}
+}
+
+func TestNestedTooDeep(t *testing.T) {
+	data := []byte("triple wrapped payload")
+	for i := 0; i < 3; i++ {
+		data = compressWith(t, New(Gzip), data)
+	}
+
+	_, err := io.ReadAll(New(Gzip, WithMaxNestingDepth(1)).Reader(bytes.NewReader(data)))
+	if !errors.Is(err, ErrNestingTooDeep) {
+		t.Fatalf("Expected ErrNestingTooDeep, got %v", err)
+	}
+}
+
+func TestNestedPlainTextLookingLikeZlib(t *testing.T) {
+	// "HK", "x^" and "8O" are valid zlib headers, but the text is not zlib
+	m := New(Gzip, WithMaxNestingDepth(1))
+	for _, text := range []string{
+		`HKEY_LOCAL_MACHINE\Software\Microsoft\Windows\CurrentVersion`,
+		"x^2 + y^2 = r^2",
+		"8O years of plain text",
+	} {
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, []byte(text)))))
+		if err != nil {
+			t.Fatalf("%q: Failed to read: %v", text, err)
+		}
+		if string(got) != text {
+			t.Fatalf("Expected %q, got %q", text, got)
+		}
+
+		// Nor does it count as a layer beyond the depth limit
+		nested := compressWith(t, m, compressWith(t, m, []byte(text)))
+		got, err = io.ReadAll(m.Reader(bytes.NewReader(nested)))
+		if err != nil {
+			t.Fatalf("%q: Failed to unwrap nested stream: %v", text, err)
+		}
+		if string(got) != text {
+			t.Fatalf("Expected %q, got %q", text, got)
+		}
+	}
+}
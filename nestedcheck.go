@@ -0,0 +1,115 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrAlreadyCompressed can be returned by a nested compression check to reject
+// input that is already compressed
+var ErrAlreadyCompressed = errors.New("input is already compressed")
+
+// nestedCheckSize is the number of leading bytes needed to recognize all formats
+const nestedCheckSize = 6
+
+// compressedFormats are the magic bytes of compression formats that are
+// recognized by the nested compression check. Zlib is deliberately missing:
+// its two byte header is matched by plain text such as "HK" or "x^".
+var compressedFormats = []struct {
+	name  string
+	magic []byte
+}{
+	{"gzip", []byte{0x1f, 0x8b, 0x08}},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{"bzip2", []byte{'B', 'Z', 'h'}},
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{"lz4", []byte{0x04, 0x22, 0x4d, 0x18}},
+}
+
+// WithNestedCompressionCheck calls fn with the format name ("gzip", "zstd",
+// "bzip2", "xz" or "lz4") when the data written to a Writer already
+// starts with the magic bytes of a compressed stream. Compressing such data
+// again wastes CPU and usually means the middleware chain is misconfigured.
+// If fn returns nil, for example after logging a warning, the data is
+// compressed as usual; otherwise the write fails with the returned error,
+// such as ErrAlreadyCompressed, before anything is compressed.
+func WithNestedCompressionCheck(fn func(format string) error) Option {
+	return func(m *Middleware) {
+		m.onNested = fn
+	}
+}
+
+// compressedFormat returns the name of the compression format p starts with
+func compressedFormat(p []byte) (string, bool) {
+	for _, f := range compressedFormats {
+		if bytes.HasPrefix(p, f.magic) {
+			return f.name, true
+		}
+	}
+	return "", false
+}
+
+// nestedCheckWriter holds back the first bytes of a stream until they have been checked
+type nestedCheckWriter struct {
+	io.Writer
+	check   func(format string) error
+	head    []byte
+	checked bool
+	err     error
+}
+
+func (w *nestedCheckWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.checked {
+		return w.Writer.Write(p)
+	}
+	n := min(len(p), nestedCheckSize-len(w.head))
+	w.head = append(w.head, p[:n]...)
+	if len(w.head) < nestedCheckSize {
+		return n, nil
+	}
+	if err := w.release(); err != nil {
+		return n, err
+	}
+	m, err := w.Writer.Write(p[n:])
+	return n + m, err
+}
+
+// release checks the held back bytes and passes them on
+func (w *nestedCheckWriter) release() error {
+	if w.checked || w.err != nil {
+		return w.err
+	}
+	w.checked = true
+	if len(w.head) == 0 {
+		return nil
+	}
+	if format, ok := compressedFormat(w.head); ok {
+		if w.err = w.check(format); w.err != nil {
+			return w.err
+		}
+	}
+	_, w.err = w.Writer.Write(w.head)
+	w.head = nil
+	return w.err
+}
+
+func (w *nestedCheckWriter) Flush() error {
+	if err := w.release(); err != nil {
+		return err
+	}
+	return flush(w.Writer)
+}
+
+func (w *nestedCheckWriter) Close() error {
+	err := w.release()
+	if closer, ok := w.Writer.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestNestedCompressionCheck_Warn(t *testing.T) {
+	inner := compressWith(t, New(Gzip), []byte("already compressed payload"))
+
+	var formats []string
+	m := New(Gzip, WithNestedCompressionCheck(func(format string) error {
+		formats = append(formats, format)
+		return nil
+	}))
+
+	// Split the magic across writes
+	var buf bytes.Buffer
+	w := m.Writer(&buf).(io.WriteCloser)
+	for _, b := range inner {
+		if _, err := w.Write([]byte{b}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if len(formats) != 1 || formats[0] != "gzip" {
+		t.Fatalf("Expected one gzip detection, got %v", formats)
+	}
+
+	got, err := io.ReadAll(m.Reader(&buf))
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, inner) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestNestedCompressionCheck_Reject(t *testing.T) {
+	m := New(Zlib, WithNestedCompressionCheck(func(format string) error {
+		return ErrAlreadyCompressed
+	}))
+
+	tests := map[string][]byte{
+		"gzip": compressWith(t, New(Gzip), []byte("payload")),
+		"zstd": {0x28, 0xb5, 0x2f, 0xfd, 0x00, 0x58, 0x00},
+		"xz":   {0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00},
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := m.Writer(&buf).(io.WriteCloser)
+			if _, err := w.Write(data); !errors.Is(err, ErrAlreadyCompressed) {
+				t.Fatalf("Expected ErrAlreadyCompressed, got %v", err)
+			}
+			if err := w.Close(); !errors.Is(err, ErrAlreadyCompressed) {
+				t.Fatalf("Expected ErrAlreadyCompressed on Close, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNestedCompressionCheck_PlainData(t *testing.T) {
+	called := false
+	m := New(Gzip, WithNestedCompressionCheck(func(format string) error {
+		called = true
+		return ErrAlreadyCompressed
+	}))
+
+	// "HK", "x^" and "8O" are valid zlib headers, but must not be flagged
+	for _, data := range [][]byte{
+		[]byte("plain text data"), []byte("hi"), nil,
+		[]byte(`HKEY_LOCAL_MACHINE\Software`), []byte("x^2 + y^2"), []byte("8O ranges"),
+	} {
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Data mismatch for %q", data)
+		}
+	}
+	if called {
+		t.Fatal("Plain data was reported as compressed")
+	}
+}
@@ -0,0 +1,242 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// The original size is stored in a gzip FEXTRA subfield (RFC 1952, 2.3.1.1):
+//
+//	'H' 'S' | length 8 (2) | uncompressed size (8, little endian)
+//
+// All bits set mean that the size is unknown. Zlib and flate streams have no
+// header field for it, so the size follows the stream in a trailer:
+//
+//	'Z' 'S' 'Z' 0 | uncompressed size (8, little endian)
+//
+// The first byte is no valid zlib header, so the trailer ends a multistream read.
+const (
+	sizeSubfieldLen = 4 + 8
+	sizeTrailerLen  = 4 + 8
+	unknownSize     = ^uint64(0)
+)
+
+var (
+	sizeSubfieldID   = [2]byte{'H', 'S'}
+	sizeTrailerMagic = [4]byte{'Z', 'S', 'Z', 0}
+)
+
+// WithOriginalSize records the uncompressed length of the stream, so readers
+// can preallocate buffers and report progress accurately; see ExpectedSizer.
+// Gzip streams carry it in a FEXTRA subfield of the header, which is written
+// before the data, so the field is reserved up front and filled in on Close.
+// This requires the wrapped writer to implement io.WriteSeeker (such as
+// *os.File); otherwise the size is recorded as unknown, and gzip streams are
+// written by the standard library. Zlib and flate streams are followed by a
+// 12-byte trailer holding the size, which readers with the option consume and
+// verify. The trailer ends the stream, so such streams are not concatenated.
+// It applies to plain streams only, without options that add layers around
+// the compressed data.
+func WithOriginalSize() Option {
+	return func(m *Middleware) {
+		m.originalSize = true
+	}
+}
+
+// recordsSize reports whether the original size is recorded in the gzip header or a trailer
+func (m *Middleware) recordsSize() bool {
+	if !m.originalSize {
+		return false
+	}
+	// The gzip header is written by the standard library regardless of the
+	// backend; the trailer follows the output of any backend
+	d := *m
+	d.backend = BackendStdlib
+	return d.resettableWriter()
+}
+
+// sizeExtra returns a FEXTRA field holding a size subfield
+func sizeExtra(size uint64) []byte {
+	extra := make([]byte, 0, sizeSubfieldLen)
+	extra = append(extra, sizeSubfieldID[:]...)
+	extra = binary.LittleEndian.AppendUint16(extra, 8)
+	return binary.LittleEndian.AppendUint64(extra, size)
+}
+
+// sizeFieldOffset is the position of the size within a gzip header written with sizeExtra
+const sizeFieldOffset = 10 + 2 + 4 // fixed header, XLEN, subfield header
+
+// OriginalSize returns the uncompressed size recorded with WithOriginalSize in
+// a gzip header, as returned by HeaderReader. It reports false if the header
+// carries no known size.
+func OriginalSize(hdr *gzip.Header) (int64, bool) {
+	extra := hdr.Extra
+	for len(extra) >= 4 {
+		id := [2]byte{extra[0], extra[1]}
+		n := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+n {
+			return 0, false
+		}
+		if id == sizeSubfieldID && n == 8 {
+			size := binary.LittleEndian.Uint64(extra[4:])
+			if size == unknownSize || size > 1<<63-1 {
+				return 0, false
+			}
+			return int64(size), true
+		}
+		extra = extra[4+n:]
+	}
+	return 0, false
+}
+
+// sizePatch fills in the reserved size field of a stream written to a seekable destination
+type sizePatch struct {
+	ws    io.WriteSeeker
+	start int64 // position of the gzip header
+}
+
+// newSizePatch returns a patch for streams written to w, or nil if w cannot be patched
+func (m *Middleware) newSizePatch(w io.Writer) *sizePatch {
+	ws, ok := w.(io.WriteSeeker)
+	if !ok || m.algorithm != Gzip || !m.recordsSize() {
+		return nil
+	}
+	start, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return &sizePatch{ws: ws, start: start}
+}
+
+// apply writes size into the header and returns to the end of the stream
+func (p *sizePatch) apply(size int64) error {
+	end, err := p.ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := p.ws.Seek(p.start+sizeFieldOffset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = p.ws.Write(binary.LittleEndian.AppendUint64(nil, uint64(size)))
+	if _, serr := p.ws.Seek(end, io.SeekStart); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// sizeTrailer reports whether zlib and flate streams end with a size trailer
+func (m *Middleware) sizeTrailer() bool {
+	return m.algorithm != Gzip && m.recordsSize()
+}
+
+// writeSizeTrailer writes the trailer recording size to w
+func writeSizeTrailer(w io.Writer, size int64) error {
+	trailer := make([]byte, 0, sizeTrailerLen)
+	trailer = append(trailer, sizeTrailerMagic[:]...)
+	trailer = binary.LittleEndian.AppendUint64(trailer, uint64(size))
+	_, err := w.Write(trailer)
+	return err
+}
+
+// parseSizeTrailer returns the size held by trailer, if it is one
+func parseSizeTrailer(trailer []byte) (int64, bool) {
+	if len(trailer) != sizeTrailerLen || [4]byte(trailer) != sizeTrailerMagic {
+		return 0, false
+	}
+	size := binary.LittleEndian.Uint64(trailer[4:])
+	if size > 1<<63-1 {
+		return 0, false
+	}
+	return int64(size), true
+}
+
+// consumeSizeTrailer discards the size trailer at the current position of src
+// and checks it against the n decompressed bytes. Streams without a trailer,
+// written before the option was enabled, are accepted.
+func consumeSizeTrailer(src *bufio.Reader, n int64) error {
+	trailer, err := src.Peek(sizeTrailerLen)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	size, ok := parseSizeTrailer(trailer)
+	if !ok {
+		return nil
+	}
+	src.Discard(sizeTrailerLen)
+	if size != n {
+		return fmt.Errorf("%w: size trailer records %d bytes, stream has %d", ErrCorruptedStream, size, n)
+	}
+	return nil
+}
+
+// sizeTrailerReader consumes the size trailer once the decompressed stream ends
+type sizeTrailerReader struct {
+	io.Reader
+	src *bufio.Reader
+	n   int64
+}
+
+func (r *sizeTrailerReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	if err == io.EOF {
+		if terr := consumeSizeTrailer(r.src, r.n); terr != nil {
+			return n, terr
+		}
+	}
+	return n, err
+}
+
+func (r *sizeTrailerReader) Close() error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ExpectedSizer is implemented by the readers returned by Reader, NewReader,
+// ReadCloser and NewResettableReader. ExpectedSize returns the uncompressed
+// size recorded with WithOriginalSize. For gzip it is taken from the header;
+// zlib and flate trailers are read from the end of the compressed input,
+// which must then implement io.ReadSeeker, such as *os.File. The read
+// position is restored afterwards. It reports false if no size is known.
+type ExpectedSizer interface {
+	ExpectedSize() (int64, bool)
+}
+
+// expectedSize returns the size recorded for the stream read from src, where
+// hr yields the gzip header
+func (m *Middleware) expectedSize(src io.Reader, hr HeaderReader) (int64, bool) {
+	if m.algorithm == Gzip {
+		hdr, ok := hr.Header()
+		if !ok {
+			return 0, false
+		}
+		return OriginalSize(hdr)
+	}
+	rs, ok := src.(io.ReadSeeker)
+	if !ok || !m.sizeTrailer() {
+		return 0, false
+	}
+	return readSizeTrailer(rs)
+}
+
+// readSizeTrailer reads the size trailer at the end of rs and restores its position
+func readSizeTrailer(rs io.ReadSeeker) (int64, bool) {
+	pos, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, false
+	}
+	defer rs.Seek(pos, io.SeekStart)
+	if _, err := rs.Seek(-sizeTrailerLen, io.SeekEnd); err != nil {
+		return 0, false
+	}
+	trailer := make([]byte, sizeTrailerLen)
+	if _, err := io.ReadFull(rs, trailer); err != nil {
+		return 0, false
+	}
+	return parseSizeTrailer(trailer)
+}
@@ -0,0 +1,132 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOriginalSize(t *testing.T) {
+	data := bytes.Repeat([]byte("preallocate me "), 5000)
+	m := New(Gzip, WithOriginalSize())
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "spill.gz"))
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer f.Close()
+
+	// The stream does not start at the beginning of the file
+	if _, err := f.Write([]byte("prefix")); err != nil {
+		t.Fatalf("Failed to write prefix: %v", err)
+	}
+	w := m.Writer(f).(io.WriteCloser)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	if _, err := f.Seek(int64(len("prefix")), io.SeekStart); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+	r := m.ReadCloser(f)
+	hdr, ok := r.(HeaderReader).Header()
+	if !ok {
+		t.Fatal("Expected a gzip header")
+	}
+	if size, ok := OriginalSize(hdr); !ok || size != int64(len(data)) {
+		t.Fatalf("Expected original size %d, got %d (%v)", len(data), size, ok)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestOriginalSize_Unknown(t *testing.T) {
+	// Without a seekable destination the size stays unknown, but the stream is valid
+	m := New(Gzip, WithOriginalSize())
+	compressed := compressWith(t, m, []byte("streamed"))
+
+	r := m.Reader(bytes.NewReader(compressed))
+	hdr, ok := r.(HeaderReader).Header()
+	if !ok {
+		t.Fatal("Expected a gzip header")
+	}
+	if _, ok := OriginalSize(hdr); ok {
+		t.Fatal("Expected unknown size")
+	}
+	if got, err := io.ReadAll(r); err != nil || string(got) != "streamed" {
+		t.Fatalf("Failed to read: %v", err)
+	}
+
+	if _, err := NewE(Gzip, WithOriginalSize(), WithStreamHeader()); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Expected ErrInvalidOption, got %v", err)
+	}
+}
+
+func TestOriginalSize_Trailer(t *testing.T) {
+	data := bytes.Repeat([]byte("trailing size "), 3000)
+	for _, alg := range []Algorithm{Zlib, Flate} {
+		m := New(alg, WithOriginalSize(), WithStrictTrailer())
+		compressed := compressWith(t, m, data)
+
+		src := bytes.NewReader(compressed)
+		r := m.Reader(src)
+		if size, ok := r.(ExpectedSizer).ExpectedSize(); !ok || size != int64(len(data)) {
+			t.Fatalf("%v: expected size %d, got %d (%v)", alg, len(data), size, ok)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%v: failed to read: %v", alg, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: data mismatch", alg)
+		}
+
+		// Readers without the option ignore the trailer
+		got, err = io.ReadAll(New(alg).Reader(bytes.NewReader(compressed)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("%v: failed to read without the option: %v", alg, err)
+		}
+
+		rr, err := New(alg, WithOriginalSize()).NewResettableReader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("%v: failed to create resettable reader: %v", alg, err)
+		}
+		if size, ok := rr.ExpectedSize(); !ok || size != int64(len(data)) {
+			t.Fatalf("%v: expected size %d from resettable reader, got %d (%v)", alg, len(data), size, ok)
+		}
+		if got, err := io.ReadAll(rr); err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("%v: failed to read with resettable reader: %v", alg, err)
+		}
+	}
+}
+
+func TestOriginalSize_TrailerMismatch(t *testing.T) {
+	m := New(Zlib, WithOriginalSize())
+	compressed := compressWith(t, m, []byte("sized"))
+	compressed[len(compressed)-8]++
+
+	if _, err := io.ReadAll(m.Reader(bytes.NewReader(compressed))); !errors.Is(err, ErrCorruptedStream) {
+		t.Fatalf("Expected ErrCorruptedStream, got %v", err)
+	}
+
+	// Streams written without the option have no trailer and no known size
+	plain := compressWith(t, New(Zlib), []byte("sized"))
+	r := m.Reader(bytes.NewReader(plain))
+	if _, ok := r.(ExpectedSizer).ExpectedSize(); ok {
+		t.Fatal("Expected unknown size")
+	}
+	if got, err := io.ReadAll(r); err != nil || string(got) != "sized" {
+		t.Fatalf("Failed to read: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package compressionstdlib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Size of an empty gzip member carrying an extra field with a single padding subfield:
+// header (10) + XLEN (2) + subfield header (4) + empty deflate block (2) + trailer (8)
+const (
+	gzipPadMemberMin  = 26
+	gzipPadMaxPayload = gzipMaxExtra - 4
+)
+
+// WithPaddingBuckets pads the compressed output of each stream up to the smallest
+// bucket size (in bytes) that fits it, so the compressed length leaks less about
+// the plaintext. Streams larger than the largest bucket are padded to a multiple
+// of it. Gzip streams are padded with empty gzip members, which any multistream
+// gzip reader skips; zlib, flate and LZW streams are padded with trailing zero
+// bytes. Other algorithms are not padded, and NewE rejects them; None is
+// accepted, as padding could not be removed from uncompressed data.
+func WithPaddingBuckets(buckets ...int) Option {
+	return func(m *Middleware) {
+		valid := make([]int, 0, len(buckets))
+		for _, b := range buckets {
+			if b > 0 {
+				valid = append(valid, b)
+			}
+		}
+		sort.Ints(valid)
+		m.paddingBuckets = valid
+	}
+}
+
+// padded reports whether streams are padded
+func (m *Middleware) padded() bool {
+	return len(m.paddingBuckets) > 0 && paddable(m.algorithm)
+}
+
+// paddable reports whether the algorithm's readers stop cleanly before
+// padding. Padding is not removable from uncompressed data, RLE would decode
+// trailing zeros as packets and the optional codecs reject trailing data.
+func paddable(algorithm Algorithm) bool {
+	switch algorithm {
+	case Gzip, Zlib, Flate, LZW:
+		return true
+	}
+	return false
+}
+
+// paddingSize returns how many bytes must be appended to a stream of the given size
+func (m *Middleware) paddingSize(size int64) int64 {
+	minPad := int64(1)
+	if m.algorithm == Gzip {
+		minPad = gzipPadMemberMin
+	}
+
+	fits := func(target int64) bool {
+		pad := target - size
+		return pad == 0 || pad >= minPad
+	}
+
+	for _, b := range m.paddingBuckets {
+		if fits(int64(b)) {
+			return int64(b) - size
+		}
+	}
+
+	largest := int64(m.paddingBuckets[len(m.paddingBuckets)-1])
+	target := (size + largest - 1) / largest * largest
+	for !fits(target) {
+		target += largest
+	}
+	return target - size
+}
+
+// countingWriter counts the bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func (w *countingWriter) Flush() error {
+	return flush(w.w)
+}
+
+// paddingWriter pads the compressed stream to the configured bucket size on Close
+type paddingWriter struct {
+	io.Writer
+	m  *Middleware
+	cw *countingWriter
+}
+
+func (w *paddingWriter) Flush() error {
+	return flush(w.Writer)
+}
+
+func (w *paddingWriter) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+
+	pad := w.m.paddingSize(w.cw.n)
+	if pad == 0 {
+		return nil
+	}
+	if w.m.algorithm != Gzip {
+		if _, err := w.cw.Write(make([]byte, pad)); err != nil {
+			return fmt.Errorf("failed to write padding: %w", err)
+		}
+		return nil
+	}
+
+	for pad > 0 {
+		member := pad
+		if member > gzipPadMemberMin+gzipPadMaxPayload {
+			member = gzipPadMemberMin + gzipPadMaxPayload
+			if rest := pad - member; rest > 0 && rest < gzipPadMemberMin {
+				member -= gzipPadMemberMin
+			}
+		}
+		if _, err := w.cw.Write(gzipPadMember(int(member - gzipPadMemberMin))); err != nil {
+			return fmt.Errorf("failed to write padding: %w", err)
+		}
+		pad -= member
+	}
+	return nil
+}
+
+// gzipPadMember builds an empty gzip member whose extra field carries n padding bytes
+func gzipPadMember(n int) []byte {
+	b := make([]byte, gzipPadMemberMin+n)
+	b[0], b[1], b[2], b[3] = 0x1f, 0x8b, 8, gzipFlagExtra
+	b[9] = 255 // unknown OS
+	binary.LittleEndian.PutUint16(b[10:], uint16(4+n))
+	b[12], b[13] = 'P', 'D'
+	binary.LittleEndian.PutUint16(b[14:], uint16(n))
+	// b[16:16+n] is zero padding
+	b[16+n], b[17+n] = 0x03, 0x00 // empty final deflate block
+	// CRC32 and ISIZE of the empty payload are zero
+	return b
+}
@@ -0,0 +1,69 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestPaddingBuckets(t *testing.T) {
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		m := New(algorithm, WithPaddingBuckets(512, 4096))
+
+		for _, size := range []int{0, 10, 600, 5000} {
+			data := make([]byte, size)
+			for i := range data {
+				data[i] = byte(i * 7919 >> 3)
+			}
+
+			compressed := compressWith(t, m, data)
+			if l := len(compressed); l != 512 && l%4096 != 0 {
+				t.Fatalf("Algorithm %d, size %d: Expected padded length, got %d", algorithm, size, l)
+			}
+
+			got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Fatalf("Algorithm %d, size %d: Failed to read padded stream: %v", algorithm, size, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("Algorithm %d, size %d: Data mismatch after padding", algorithm, size)
+			}
+		}
+	}
+}
+
+func TestPaddingLargeGzipGap(t *testing.T) {
+	// Padding larger than a single extra field spans several members
+	m := New(Gzip, WithPaddingBuckets(200000))
+	compressed := compressWith(t, m, []byte("small"))
+	if len(compressed) != 200000 {
+		t.Fatalf("Expected 200000 bytes, got %d", len(compressed))
+	}
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to read padded stream: %v", err)
+	}
+	if string(got) != "small" {
+		t.Fatalf("Expected %q, got %q", "small", got)
+	}
+}
+
+func TestPaddingRLE(t *testing.T) {
+	// Trailing zeros would decode as RLE packets, so RLE streams are not padded
+	m := New(RLE, WithPaddingBuckets(2048))
+	for _, size := range []int{0, 1000, 156000} {
+		data := bytes.Repeat([]byte("run length "), size/11)
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+		if err != nil {
+			t.Fatalf("Size %d: Failed to read: %v", size, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Size %d: Data mismatch, got %d bytes", size, len(got))
+		}
+	}
+	if _, err := NewE(RLE, WithPaddingBuckets(2048)); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Expected ErrInvalidOption, got %v", err)
+	}
+}
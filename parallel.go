@@ -0,0 +1,208 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// parallelBlockSize is the amount of uncompressed data per gzip member in parallel mode
+	parallelBlockSize = 1 << 20
+
+	// storedRatio is the compressed to original size ratio of a block sample
+	// above which the block is written as stored deflate blocks
+	storedRatio = 0.98
+
+	// sampleParts and samplePartSize describe the slices of a block that
+	// incompressible compresses
+	sampleParts    = 8
+	samplePartSize = 512
+)
+
+// WithParallel compresses gzip data with n workers. The input is split into
+// 1MB blocks that are compressed concurrently and written in order as
+// separate gzip members, so the output remains standard multi-member gzip
+// that any gzip reader decodes. With WithStoredPassthrough, blocks that do
+// not compress are written as stored deflate blocks. Values below 2 disable parallel compression. The option
+// applies to gzip only; NewE rejects it for other algorithms.
+func WithParallel(n int) Option {
+	return func(m *Middleware) {
+		if n >= 2 {
+			m.parallel = n
+		} else {
+			m.parallel = 0
+		}
+	}
+}
+
+// parallelWriter compresses blocks concurrently and writes the resulting gzip members in order
+type parallelWriter struct {
+	w      io.Writer
+	level  int
+	header gzip.Header
+	stored bool // store incompressible blocks
+	buf    []byte
+
+	slots   chan struct{}  // limits the blocks in flight to the worker count
+	last    chan struct{}  // closed once the last submitted block is written
+	wg      sync.WaitGroup // blocks submitted but not yet written
+	written bool           // whether any member was submitted
+	closed  bool
+
+	mu  sync.Mutex
+	err error
+}
+
+func newParallelWriter(w io.Writer, level, workers int, header gzip.Header, stored bool) *parallelWriter {
+	return &parallelWriter{
+		w:      w,
+		level:  level,
+		header: header,
+		stored: stored,
+		buf:    make([]byte, 0, parallelBlockSize),
+		slots:  make(chan struct{}, workers),
+	}
+}
+
+func (w *parallelWriter) fail(err error) {
+	w.mu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+}
+
+func (w *parallelWriter) failed() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// submit hands the buffered block to a worker. The worker writes its member
+// after the previous block's and then exits, so no goroutine outlives the
+// submitted blocks, even if the writer is dropped without Close.
+func (w *parallelWriter) submit() {
+	block := w.buf
+	w.buf = make([]byte, 0, parallelBlockSize)
+	w.written = true
+
+	prev, done := w.last, make(chan struct{})
+	w.last = done
+	w.slots <- struct{}{}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		defer func() { <-w.slots }()
+		defer close(done)
+
+		level := w.level
+		if w.stored && incompressible(block) {
+			level = flate.NoCompression
+		}
+		member, err := compressMember(block, level, w.header)
+		if prev != nil {
+			<-prev
+		}
+		switch {
+		case err != nil:
+			w.fail(err)
+		case w.failed() == nil:
+			if _, err := w.w.Write(member); err != nil {
+				w.fail(err)
+			}
+		}
+	}()
+}
+
+// compressMember compresses block into a complete gzip member
+func compressMember(block []byte, level int, header gzip.Header) ([]byte, error) {
+	var out bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&out, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip writer: %w: %v", ErrInvalidLevel, err)
+	}
+	gz.Header = header
+	if _, err := gz.Write(block); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func (w *parallelWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	n := 0
+	for len(p) > 0 {
+		if err := w.failed(); err != nil {
+			return n, err
+		}
+		c := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		n += c
+		p = p[c:]
+		if len(w.buf) == cap(w.buf) {
+			w.submit()
+		}
+	}
+	return n, nil
+}
+
+// Flush compresses the buffered data and waits until all members are written
+func (w *parallelWriter) Flush() error {
+	if w.closed {
+		return w.failed()
+	}
+	if len(w.buf) > 0 {
+		w.submit()
+	}
+	w.wg.Wait()
+	if err := w.failed(); err != nil {
+		return err
+	}
+	return flush(w.w)
+}
+
+func (w *parallelWriter) Close() error {
+	if w.closed {
+		return w.failed()
+	}
+	// Empty input still produces one (empty) member, like gzip.Writer
+	if len(w.buf) > 0 || !w.written {
+		w.submit()
+	}
+	w.closed = true
+	w.wg.Wait()
+	return w.failed()
+}
+
+// incompressible reports whether block barely shrinks at the fastest level,
+// in which case compressing it wastes CPU. It compresses one slice from the
+// middle of each of sampleParts equal segments, so data anywhere in the block
+// is accounted for.
+func incompressible(block []byte) bool {
+	sample := block
+	if len(block) > sampleParts*samplePartSize {
+		segment := len(block) / sampleParts
+		sample = make([]byte, 0, sampleParts*samplePartSize)
+		for i := 0; i < sampleParts; i++ {
+			start := i*segment + (segment-samplePartSize)/2
+			sample = append(sample, block[start:start+samplePartSize]...)
+		}
+	}
+	if len(sample) == 0 {
+		return false
+	}
+	cw := &countingWriter{w: io.Discard}
+	fw, _ := flate.NewWriter(cw, flate.BestSpeed)
+	fw.Write(sample)
+	fw.Close()
+	return float64(cw.n) >= storedRatio*float64(len(sample))
+}
@@ -0,0 +1,145 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParallelGzip(t *testing.T) {
+	data := make([]byte, 5*parallelBlockSize+12345)
+	for i := range data {
+		data[i] = byte(i * i >> 10)
+	}
+
+	for _, size := range []int{0, 100, parallelBlockSize, len(data)} {
+		m := New(Gzip, WithParallel(4))
+		compressed := compressWith(t, m, data[:size])
+
+		// Standard gzip readers decode the concatenated members
+		zr, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("%d: Failed to create gzip reader: %v", size, err)
+		}
+		got, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("%d: Failed to read: %v", size, err)
+		}
+		if !bytes.Equal(got, data[:size]) {
+			t.Fatalf("%d: Data mismatch", size)
+		}
+	}
+}
+
+func TestParallelFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(Gzip, WithParallel(2)).Writer(&buf).(io.WriteCloser)
+	w.Write([]byte("first"))
+	if err := flush(w); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil || string(got) != "first" {
+		t.Fatalf("Expected flushed data, got %q, %v", got, err)
+	}
+	w.Close()
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestParallelWriteError(t *testing.T) {
+	w := New(Gzip, WithParallel(2)).Writer(failingWriter{}).(io.WriteCloser)
+	w.Write(make([]byte, 3*parallelBlockSize))
+	if err := w.Close(); err == nil {
+		t.Fatal("Expected write error")
+	}
+}
+
+func TestParallelStoresIncompressibleBlocks(t *testing.T) {
+	noise := make([]byte, parallelBlockSize)
+	rand.New(rand.NewSource(1)).Read(noise)
+	text := bytes.Repeat([]byte("compressible text\n"), parallelBlockSize/18)
+	data := append(append([]byte{}, noise...), text...)
+
+	compressed := compressWith(t, New(Gzip, WithParallel(2), WithLevel(9), WithStoredPassthrough()), data)
+
+	// The first deflate block after the 10-byte gzip header is a stored block (BTYPE 00)
+	if btype := compressed[10] >> 1 & 3; btype != 0 {
+		t.Fatalf("Expected stored block for noise, got BTYPE %d", btype)
+	}
+	if len(compressed) > len(noise)+len(text)/10 {
+		t.Fatalf("Output too large: %d bytes", len(compressed))
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestParallelStoredPassthroughSamplesWholeBlock(t *testing.T) {
+	// Compressible text in the first 4KB, noise in the rest of the block
+	data := make([]byte, parallelBlockSize)
+	rand.New(rand.NewSource(2)).Read(data)
+	copy(data, bytes.Repeat([]byte("compressible text\n"), 4096/18))
+
+	// A stored block is written exactly like level 0
+	uncompressed := compressWith(t, New(Gzip, WithParallel(2), WithLevel(0)), data)
+	stored := compressWith(t, New(Gzip, WithParallel(2), WithLevel(9), WithStoredPassthrough()), data)
+	if !bytes.Equal(stored, uncompressed) {
+		t.Fatal("Expected stored block")
+	}
+	if compressed := compressWith(t, New(Gzip, WithParallel(2), WithLevel(9)), data); bytes.Equal(compressed, uncompressed) {
+		t.Fatal("Expected compressed block without WithStoredPassthrough")
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestParallelDroppedWriterExits(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for _, dst := range []io.Writer{io.Discard, failingWriter{}} {
+		w := New(Gzip, WithParallel(4)).Writer(dst)
+		w.Write(make([]byte, 3*parallelBlockSize+100))
+		// Dropped without Close
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d goroutines after dropping writers, got %d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
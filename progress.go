@@ -0,0 +1,93 @@
+package compressionstdlib
+
+import (
+	"io"
+	"time"
+)
+
+// progressInterval is the minimum time between two progress callbacks
+const progressInterval = 100 * time.Millisecond
+
+// Progress describes how far a Reader has consumed its compressed source
+type Progress struct {
+	// CompressedRead is the number of compressed bytes consumed so far
+	CompressedRead int64
+	// CompressedTotal is the size set with WithCompressedSize, or 0 if unknown
+	CompressedTotal int64
+	// Percent is the share of the source consumed (0-100), or -1 if the size is unknown
+	Percent float64
+	// Elapsed is the time since the first read
+	Elapsed time.Duration
+	// ETA estimates the remaining time from the average rate so far, or -1 if unknown
+	ETA time.Duration
+	// Done is set on the final report, when the source is exhausted
+	Done bool
+}
+
+// WithCompressedSize sets the expected compressed size of streams read through
+// this middleware, enabling percentages and ETAs in progress reports
+func WithCompressedSize(n int64) Option {
+	return func(m *Middleware) {
+		if n > 0 {
+			m.compressedSize = n
+		}
+	}
+}
+
+// WithReadProgress registers a callback reporting read progress based on the
+// compressed bytes consumed. It is invoked at most every 100ms and once more
+// when the source is exhausted.
+func WithReadProgress(fn func(Progress)) Option {
+	return func(m *Middleware) {
+		m.readProgress = fn
+	}
+}
+
+// progressReader reports consumption of the compressed source
+type progressReader struct {
+	r     io.Reader
+	fn    func(Progress)
+	total int64
+	n     int64
+	start time.Time
+	last  time.Time
+	done  bool
+}
+
+func (r *progressReader) Read(p []byte) (n int, err error) {
+	now := time.Now()
+	if r.start.IsZero() {
+		r.start = now
+	}
+	n, err = r.r.Read(p)
+	r.n += int64(n)
+
+	if err == io.EOF && !r.done {
+		r.done = true
+		r.report(time.Now())
+	} else if now.Sub(r.last) >= progressInterval {
+		r.report(now)
+	}
+	return n, err
+}
+
+func (r *progressReader) report(now time.Time) {
+	r.last = now
+	p := Progress{
+		CompressedRead:  r.n,
+		CompressedTotal: r.total,
+		Percent:         -1,
+		Elapsed:         now.Sub(r.start),
+		ETA:             -1,
+		Done:            r.done,
+	}
+	if r.total > 0 {
+		p.Percent = min(100, float64(r.n)/float64(r.total)*100)
+		if r.done || r.n >= r.total {
+			p.ETA = 0
+		} else if r.n > 0 {
+			p.ETA = time.Duration(float64(p.Elapsed) * float64(r.total-r.n) / float64(r.n))
+		}
+	}
+	r.fn(p)
+}
@@ -0,0 +1,49 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestReadProgress(t *testing.T) {
+	data := bytes.Repeat([]byte("progress "), 100000)
+	compressed := compressWith(t, New(Gzip), data)
+
+	var reports []Progress
+	m := New(Gzip,
+		WithCompressedSize(int64(len(compressed))),
+		WithReadProgress(func(p Progress) { reports = append(reports, p) }),
+	)
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+
+	if len(reports) == 0 {
+		t.Fatal("Expected progress reports")
+	}
+	final := reports[len(reports)-1]
+	if !final.Done || final.Percent != 100 || final.ETA != 0 {
+		t.Fatalf("Unexpected final report: %+v", final)
+	}
+	if final.CompressedRead != int64(len(compressed)) {
+		t.Fatalf("Expected %d compressed bytes read, got %d", len(compressed), final.CompressedRead)
+	}
+}
+
+func TestReadProgress_UnknownSize(t *testing.T) {
+	compressed := compressWith(t, New(Zlib), []byte("unknown size"))
+
+	var final Progress
+	m := New(Zlib, WithReadProgress(func(p Progress) { final = p }))
+	io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+
+	if final.Percent != -1 || final.ETA != -1 {
+		t.Fatalf("Expected unknown percent and ETA, got %+v", final)
+	}
+}
@@ -0,0 +1,66 @@
+package compressionstdlib
+
+import "io"
+
+// WithMinRatio stores streams uncompressed when compression does not pay off.
+// The Writer buffers the first few KB (see WithSampleSize) and compresses them
+// with the configured algorithm and level. If the sample does not shrink to
+// at most ratio times its size, as with encrypted or already compressed
+// payloads, the stream is written uncompressed; otherwise it is compressed as
+// usual. The choice is recorded in a stream header, so Readers need the
+// option as well, or WithStreamHeader. Values outside (0, 1] are ignored. The
+// option has no effect with Auto and NewLazy, which select per stream anyway.
+func WithMinRatio(ratio float64) Option {
+	return func(m *Middleware) {
+		if ratio > 0 && ratio <= 1 {
+			m.minRatio = ratio
+			m.selector = (*Middleware).thresholdSample
+		}
+	}
+}
+
+// WithMinSize stores streams shorter than n bytes uncompressed, since the
+// framing of small payloads outweighs what compression saves. The Writer
+// buffers up to n bytes (at least the sample size, see WithSampleSize) before
+// committing, so a Flush before that point commits to storing the stream. The
+// choice is recorded in a stream header, so Readers need the option as well,
+// or WithStreamHeader. Values of zero or below are ignored. Like WithMinRatio,
+// with which it combines, it has no effect with Auto and NewLazy.
+func WithMinSize(n int) Option {
+	return func(m *Middleware) {
+		if n > 0 {
+			m.minSize = n
+			m.selector = (*Middleware).thresholdSample
+		}
+	}
+}
+
+// thresholdSample stores streams that are too short or do not compress to the
+// configured ratio, and keeps the configured algorithm and level otherwise
+func (m *Middleware) thresholdSample(sample []byte) (Algorithm, int) {
+	if len(sample) < m.minSize {
+		return None, 0
+	}
+	if m.minRatio > 0 {
+		return m.ratioSample(sample)
+	}
+	return m.algorithm, m.level
+}
+
+// ratioSample keeps the configured algorithm and level if the sample
+// compresses to the configured ratio, and stores the stream otherwise
+func (m *Middleware) ratioSample(sample []byte) (Algorithm, int) {
+	if len(sample) == 0 {
+		return m.algorithm, m.level
+	}
+	cw := &countingWriter{w: io.Discard}
+	w := asWriteCloser(m.derive(m.algorithm, m.level).compressor(cw))
+	_, err := w.Write(sample)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && float64(cw.n) > m.minRatio*float64(len(sample)) {
+		return None, 0
+	}
+	return m.algorithm, m.level
+}
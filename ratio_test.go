@@ -0,0 +1,87 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestMinRatio(t *testing.T) {
+	random := make([]byte, 64<<10)
+	rand.New(rand.NewSource(1)).Read(random)
+	text := bytes.Repeat([]byte("compresses well enough "), 3000)
+
+	m := New(Gzip, WithMinRatio(0.9))
+	for _, tc := range []struct {
+		name   string
+		data   []byte
+		stored bool
+	}{
+		{"random", random, true},
+		{"text", text, false},
+		{"short", []byte("x"), true},
+		{"empty", nil, false},
+	} {
+		compressed := compressWith(t, m, tc.data)
+		stored := len(compressed) >= len(tc.data) && len(tc.data) > 0
+		if stored != tc.stored {
+			t.Fatalf("%s: expected stored=%v, got %d bytes for %d", tc.name, tc.stored, len(compressed), len(tc.data))
+		}
+		if tc.stored && len(compressed) > len(tc.data)+16 {
+			t.Fatalf("%s: expected little overhead, got %d bytes for %d", tc.name, len(compressed), len(tc.data))
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%s: failed to read: %v", tc.name, err)
+		}
+		if !bytes.Equal(got, tc.data) {
+			t.Fatalf("%s: data mismatch", tc.name)
+		}
+	}
+
+	if alg, _ := m.ratioSample(random[:4096]); alg != None {
+		t.Fatalf("Expected None for random data, got %v", alg)
+	}
+	// Copies made with With measure at their own level
+	if alg, level := m.WithLevel(BestSpeed).ratioSample(text[:4096]); alg != Gzip || level != 1 {
+		t.Fatalf("Expected gzip level 1, got %v level %d", alg, level)
+	}
+	if New(Gzip, WithMinRatio(1.5)).minRatio != 0 {
+		t.Fatal("Expected an invalid ratio to be ignored")
+	}
+}
+
+func TestMinSize(t *testing.T) {
+	m := New(Gzip, WithMinSize(8192))
+	small := bytes.Repeat([]byte("tiny "), 40)
+	large := bytes.Repeat([]byte("large enough to compress "), 1000)
+
+	compressed := compressWith(t, m, small)
+	if len(compressed) > len(small)+16 || !bytes.Contains(compressed, small) {
+		t.Fatalf("Expected %d bytes to be stored, got %d", len(small), len(compressed))
+	}
+	if c := compressWith(t, m, large); len(c) >= len(large)/4 {
+		t.Fatalf("Expected %d bytes to be compressed, got %d", len(large), len(c))
+	}
+	for _, data := range [][]byte{small, large, nil} {
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressWith(t, m, data))))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("Failed to read %d bytes: %v", len(data), err)
+		}
+	}
+
+	// Combined with WithMinRatio, large payloads are still measured
+	random := make([]byte, 16<<10)
+	rand.New(rand.NewSource(2)).Read(random)
+	both := New(Gzip, WithMinSize(1024), WithMinRatio(0.9))
+	if alg, _ := both.thresholdSample(random); alg != None {
+		t.Fatalf("Expected random data to be stored, got %v", alg)
+	}
+	if alg, _ := both.thresholdSample(large); alg != Gzip {
+		t.Fatalf("Expected text to be compressed, got %v", alg)
+	}
+	if New(Gzip, WithMinSize(0)).selector != nil {
+		t.Fatal("Expected a non-positive size to be ignored")
+	}
+}
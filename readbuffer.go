@@ -0,0 +1,24 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"io"
+)
+
+// WithReadBufferSize reads the compressed input in chunks of n bytes. The
+// decoders read in small pieces, which is slow on sources with a high cost
+// per call, such as remote storage backends. Values of zero or below disable
+// the buffer.
+func WithReadBufferSize(n int) Option {
+	return func(m *Middleware) {
+		m.readBufferSize = max(n, 0)
+	}
+}
+
+// readBuffering wraps the compressed input r in the configured read buffer
+func (m *Middleware) readBuffering(r io.Reader) io.Reader {
+	if m.readBufferSize == 0 {
+		return r
+	}
+	return bufio.NewReaderSize(r, m.readBufferSize)
+}
@@ -0,0 +1,55 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// readRecorder records the size of each read
+type readRecorder struct {
+	r     io.Reader
+	sizes []int
+}
+
+func (r *readRecorder) Read(p []byte) (int, error) {
+	r.sizes = append(r.sizes, len(p))
+	return r.r.Read(p)
+}
+
+func TestReadBufferSize(t *testing.T) {
+	data := bytes.Repeat([]byte("remote object "), 20000)
+	const size = 256 << 10
+	for _, alg := range []Algorithm{Gzip, Zlib, Flate} {
+		m := New(alg, WithReadBufferSize(size))
+		compressed := compressWith(t, m, data)
+
+		src := &readRecorder{r: bytes.NewReader(compressed)}
+		got, err := io.ReadAll(m.Reader(src))
+		if err != nil {
+			t.Fatalf("%v: failed to read: %v", alg, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: data mismatch", alg)
+		}
+		for _, n := range src.sizes {
+			if n < size {
+				t.Fatalf("%v: expected reads of %d bytes, got %d", alg, size, n)
+			}
+		}
+
+		src = &readRecorder{r: bytes.NewReader(compressed)}
+		rr, err := m.NewResettableReader(src)
+		if err != nil {
+			t.Fatalf("%v: failed to create resettable reader: %v", alg, err)
+		}
+		if got, err := io.ReadAll(rr); err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("%v: failed to read with resettable reader: %v", alg, err)
+		}
+		for _, n := range src.sizes {
+			if n < size {
+				t.Fatalf("%v: expected reads of %d bytes from resettable reader, got %d", alg, size, n)
+			}
+		}
+	}
+}
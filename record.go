@@ -0,0 +1,181 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Record framing inside the decompressed stream:
+//
+//	magic (4) | payload length (4) | payload CRC32 (4) | header CRC32 (4) | payload
+//
+// The header checksum lets readers reject a corrupted length before trusting
+// it, so they can resynchronize on the next magic without losing records.
+const (
+	recordHeaderSize = 16
+	// MaxRecordSize is the largest record accepted by RecordWriter and RecordReader
+	MaxRecordSize = 64 << 20
+)
+
+var recordMagic = []byte{0xab, 'R', 'E', 'C'}
+
+// ErrRecordTooLarge is returned when appending a record larger than MaxRecordSize
+var ErrRecordTooLarge = errors.New("record exceeds maximum size")
+
+// flush flushes w if it supports flushing
+func flush(w io.Writer) error {
+	if flusher, ok := w.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// RecordWriter writes length-prefixed records into a compressed stream,
+// preserving record boundaries through compression
+type RecordWriter struct {
+	w         io.Writer
+	flushEach bool
+	hdr       [recordHeaderSize]byte
+}
+
+// NewRecordWriter creates a RecordWriter compressing records into w. If flushEach
+// is set, every record is flushed to w as soon as it is appended, so a reader (or
+// a crash) never sees a partially written record from an earlier append.
+func (m *Middleware) NewRecordWriter(w io.Writer, flushEach bool) *RecordWriter {
+	return &RecordWriter{w: m.Writer(w), flushEach: flushEach}
+}
+
+// AppendRecord writes a single record
+func (rw *RecordWriter) AppendRecord(p []byte) error {
+	if len(p) > MaxRecordSize {
+		return ErrRecordTooLarge
+	}
+	copy(rw.hdr[:], recordMagic)
+	binary.LittleEndian.PutUint32(rw.hdr[4:], uint32(len(p)))
+	binary.LittleEndian.PutUint32(rw.hdr[8:], crc32.ChecksumIEEE(p))
+	binary.LittleEndian.PutUint32(rw.hdr[12:], crc32.ChecksumIEEE(rw.hdr[:12]))
+
+	if _, err := rw.w.Write(rw.hdr[:]); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	if _, err := rw.w.Write(p); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	if rw.flushEach {
+		if err := flush(rw.w); err != nil {
+			return fmt.Errorf("failed to flush record: %w", err)
+		}
+	}
+	return nil
+}
+
+// Flush flushes all appended records to the underlying writer
+func (rw *RecordWriter) Flush() error {
+	return flush(rw.w)
+}
+
+// Close finalizes the compressed stream
+func (rw *RecordWriter) Close() error {
+	if closer, ok := rw.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// RecordReader iterates over records written by RecordWriter. Corrupted records
+// are skipped by resynchronizing on the next valid record header.
+type RecordReader struct {
+	dr      io.Reader
+	br      *bufio.Reader
+	skipped int64
+}
+
+// NewRecordReader creates a RecordReader decompressing records from r
+func (m *Middleware) NewRecordReader(r io.Reader) *RecordReader {
+	dr := m.Reader(r)
+	return &RecordReader{dr: dr, br: bufio.NewReader(dr)}
+}
+
+// Next returns the next record. It returns io.EOF after the last record and
+// io.ErrUnexpectedEOF if the stream ends inside a record.
+func (rr *RecordReader) Next() ([]byte, error) {
+	for {
+		hdr, err := rr.br.Peek(recordHeaderSize)
+		if len(hdr) == 0 && err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		size := binary.LittleEndian.Uint32(hdr[4:])
+		if !bytes.Equal(hdr[:4], recordMagic) ||
+			crc32.ChecksumIEEE(hdr[:12]) != binary.LittleEndian.Uint32(hdr[12:]) ||
+			size > MaxRecordSize {
+			if err := rr.resync(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		sum := binary.LittleEndian.Uint32(hdr[8:])
+
+		rr.br.Discard(recordHeaderSize)
+		p := make([]byte, size)
+		if _, err := io.ReadFull(rr.br, p); err != nil {
+			return nil, noEOF(err)
+		}
+		if crc32.ChecksumIEEE(p) != sum {
+			// The header is intact, so the length can be trusted to skip the record
+			rr.skipped += recordHeaderSize + int64(size)
+			continue
+		}
+		return p, nil
+	}
+}
+
+// resync discards data up to the next record magic
+func (rr *RecordReader) resync() error {
+	rr.br.Discard(1)
+	rr.skipped++
+	for {
+		buf, err := rr.br.Peek(rr.br.Size())
+		if i := bytes.Index(buf, recordMagic); i >= 0 {
+			rr.br.Discard(i)
+			rr.skipped += int64(i)
+			return nil
+		}
+		// Keep a possible partial magic at the end of the buffer
+		n := len(buf) - len(recordMagic) + 1
+		if n > 0 {
+			rr.br.Discard(n)
+			rr.skipped += int64(n)
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+	}
+}
+
+// Skipped returns the number of decompressed bytes skipped while recovering from corruption
+func (rr *RecordReader) Skipped() int64 {
+	return rr.skipped
+}
+
+// Close releases the underlying decompressor
+func (rr *RecordReader) Close() error {
+	if closer, ok := rr.dr.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	m := New(Gzip)
+
+	var buf bytes.Buffer
+	rw := m.NewRecordWriter(&buf, false)
+	var records [][]byte
+	for i := 0; i < 100; i++ {
+		record := []byte(fmt.Sprintf("log line %d", i))
+		records = append(records, record)
+		if err := rw.AppendRecord(record); err != nil {
+			t.Fatalf("Failed to append record: %v", err)
+		}
+	}
+	if err := rw.AppendRecord(nil); err != nil {
+		t.Fatalf("Failed to append empty record: %v", err)
+	}
+	records = append(records, []byte{})
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Failed to close record writer: %v", err)
+	}
+
+	rr := m.NewRecordReader(&buf)
+	for i, want := range records {
+		got, err := rr.Next()
+		if err != nil {
+			t.Fatalf("Record %d: Failed to read: %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Record %d: Expected %q, got %q", i, want, got)
+		}
+	}
+	if _, err := rr.Next(); err != io.EOF {
+		t.Fatalf("Expected io.EOF after last record, got %v", err)
+	}
+}
+
+func TestRecordFlushedBeforeCrash(t *testing.T) {
+	m := New(Zlib)
+
+	// The writer is never closed, simulating a crashed log shipper
+	var buf bytes.Buffer
+	rw := m.NewRecordWriter(&buf, true)
+	rw.AppendRecord([]byte("first"))
+	rw.AppendRecord([]byte("second"))
+
+	rr := m.NewRecordReader(bytes.NewReader(buf.Bytes()))
+	for _, want := range []string{"first", "second"} {
+		got, err := rr.Next()
+		if err != nil {
+			t.Fatalf("Failed to read flushed record: %v", err)
+		}
+		if string(got) != want {
+			t.Fatalf("Expected %q, got %q", want, got)
+		}
+	}
+	if _, err := rr.Next(); !errors.Is(err, io.ErrUnexpectedEOF) || !errors.Is(err, ErrTruncatedStream) {
+		t.Fatalf("Expected io.ErrUnexpectedEOF for unfinished stream, got %v", err)
+	}
+}
+
+func TestRecordResync(t *testing.T) {
+	m := New(Gzip)
+
+	var buf bytes.Buffer
+	rw := m.NewRecordWriter(&buf, false)
+	rw.AppendRecord([]byte("before"))
+	// Inject garbage and a record with a corrupted header
+	rw.w.Write([]byte("garbage\xabREC garbage"))
+	rw.AppendRecord([]byte("after"))
+	rw.Close()
+
+	rr := m.NewRecordReader(&buf)
+	for _, want := range []string{"before", "after"} {
+		got, err := rr.Next()
+		if err != nil {
+			t.Fatalf("Failed to read record: %v", err)
+		}
+		if string(got) != want {
+			t.Fatalf("Expected %q, got %q", want, got)
+		}
+	}
+	if _, err := rr.Next(); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+	if rr.Skipped() != int64(len("garbage\xabREC garbage")) {
+		t.Fatalf("Expected skipped garbage to be reported, got %d", rr.Skipped())
+	}
+}
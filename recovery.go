@@ -0,0 +1,154 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// SkippedRange describes compressed bytes skipped while recovering from a corrupted gzip member
+type SkippedRange struct {
+	// Offset is the position of the corrupted member in the compressed input
+	Offset int64
+	// Length is the number of compressed bytes skipped up to the next gzip header
+	Length int64
+	// Err is the error that made the member unreadable
+	Err error
+}
+
+// WithCorruptionRecovery enables a salvage mode for multi-member gzip input:
+// a corrupted or truncated member is skipped and reading resumes at the next
+// gzip header, so partially damaged spill files stay readable. Each skipped
+// range is reported to onSkip. Data decoded from a member before its damage
+// was detected has already been returned. I/O errors of the underlying reader
+// are not recovered.
+func WithCorruptionRecovery(onSkip func(SkippedRange)) Option {
+	return func(m *Middleware) {
+		m.onSkip = onSkip
+	}
+}
+
+// recoveryReader reads gzip members one at a time, resynchronizing at the next
+// gzip magic after a corrupted member
+type recoveryReader struct {
+	src    *countingReader
+	br     *bufio.Reader
+	zr     *gzip.Reader
+	open   bool  // whether zr is positioned inside a member
+	start  int64 // offset of the current member
+	onSkip func(SkippedRange)
+	limits *headerLimits // checked for every member, if set
+	single bool          // stop after the first readable member
+	err    error
+}
+
+func newRecoveryReader(r io.Reader, onSkip func(SkippedRange), limits *headerLimits) *recoveryReader {
+	src := &countingReader{r: r}
+	size := 4096
+	if limits != nil {
+		size = max(size, limits.bufferSize())
+	}
+	return &recoveryReader{src: src, br: bufio.NewReaderSize(src, size), onSkip: onSkip, limits: limits}
+}
+
+// offset returns the position of the next unread byte in the compressed input
+func (r *recoveryReader) offset() int64 {
+	return r.src.n - int64(r.br.Buffered())
+}
+
+func (r *recoveryReader) Read(p []byte) (int, error) {
+	for r.err == nil {
+		if !r.open {
+			r.next()
+			continue
+		}
+		n, err := r.zr.Read(p)
+		switch {
+		case err == nil:
+			return n, nil
+		case err == io.EOF && r.single:
+			r.err = io.EOF
+		case err == io.EOF:
+			r.open = false
+		case recoverable(err):
+			r.skip(err)
+		default:
+			r.err = err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+	return 0, r.err
+}
+
+// next opens the member at the current position
+func (r *recoveryReader) next() {
+	if _, err := r.br.Peek(1); err != nil {
+		r.err = err
+		return
+	}
+	r.start = r.offset()
+	// Oversized headers are rejected rather than skipped as corruption
+	if r.limits != nil {
+		if _, err := parseGzipHeader(r.br, *r.limits); err != nil {
+			r.err = err
+			return
+		}
+	}
+	var err error
+	if r.zr == nil {
+		r.zr, err = gzip.NewReader(r.br)
+	} else {
+		err = r.zr.Reset(r.br)
+	}
+	switch {
+	case err == nil:
+		r.zr.Multistream(false)
+		r.open = true
+	case recoverable(err):
+		r.skip(err)
+	default:
+		r.err = err
+	}
+}
+
+// skip discards input up to the next gzip header and reports the skipped range
+func (r *recoveryReader) skip(cause error) {
+	r.open = false
+	if r.offset() == r.start {
+		r.br.Discard(1)
+	}
+	for {
+		hdr, err := r.br.Peek(3)
+		if len(hdr) == 3 && hdr[0] == 0x1f && hdr[1] == 0x8b && hdr[2] == 8 {
+			break
+		}
+		if err != nil {
+			if err != io.EOF {
+				r.err = err
+				return
+			}
+			r.br.Discard(len(hdr))
+			break
+		}
+		r.br.Discard(1)
+	}
+	if r.onSkip != nil {
+		r.onSkip(SkippedRange{Offset: r.start, Length: r.offset() - r.start, Err: classify(cause)})
+	}
+}
+
+// recoverable reports whether err is caused by damaged data rather than by the underlying reader
+func recoverable(err error) bool {
+	err = classify(err)
+	return errors.Is(err, ErrCorruptedStream) || errors.Is(err, ErrTruncatedStream)
+}
+
+func (r *recoveryReader) Close() error {
+	if r.zr == nil {
+		return nil
+	}
+	return r.zr.Close()
+}
@@ -0,0 +1,69 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestCorruptionRecovery(t *testing.T) {
+	var members [][]byte
+	var input []byte
+	for _, s := range []string{"first member\n", "second member\n", "third member\n"} {
+		member := compressWith(t, New(Gzip), bytes.Repeat([]byte(s), 50))
+		members = append(members, member)
+		input = append(input, member...)
+	}
+	// Damage the deflate data of the second member
+	damaged := len(members[0]) + 12
+	input[damaged] ^= 0xff
+
+	var skipped []SkippedRange
+	m := New(Gzip, WithCorruptionRecovery(func(s SkippedRange) {
+		skipped = append(skipped, s)
+	}))
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(input)))
+	if err != nil {
+		t.Fatalf("Failed to read damaged input: %v", err)
+	}
+
+	if !bytes.HasPrefix(got, bytes.Repeat([]byte("first member\n"), 50)) {
+		t.Fatal("Missing data of the first member")
+	}
+	if !bytes.HasSuffix(got, bytes.Repeat([]byte("third member\n"), 50)) {
+		t.Fatal("Missing data of the third member")
+	}
+
+	if len(skipped) != 1 {
+		t.Fatalf("Expected one skipped range, got %+v", skipped)
+	}
+	want := SkippedRange{Offset: int64(len(members[0])), Length: int64(len(members[1]))}
+	if skipped[0].Offset != want.Offset || skipped[0].Length != want.Length {
+		t.Fatalf("Expected range %d+%d, got %d+%d", want.Offset, want.Length, skipped[0].Offset, skipped[0].Length)
+	}
+	if !errors.Is(skipped[0].Err, ErrCorruptedStream) {
+		t.Fatalf("Expected ErrCorruptedStream, got %v", skipped[0].Err)
+	}
+}
+
+func TestCorruptionRecovery_TruncatedTail(t *testing.T) {
+	first := compressWith(t, New(Gzip), []byte("complete"))
+	second := compressWith(t, New(Gzip), []byte("cut short by a crash"))
+	input := append(first, second[:len(second)-5]...)
+
+	var skipped []SkippedRange
+	m := New(Gzip, WithCorruptionRecovery(func(s SkippedRange) {
+		skipped = append(skipped, s)
+	}))
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(input)))
+	if err != nil {
+		t.Fatalf("Failed to read truncated input: %v", err)
+	}
+	if !bytes.HasPrefix(got, []byte("complete")) {
+		t.Fatalf("Missing data of the complete member, got %q", got)
+	}
+	if len(skipped) != 1 || !errors.Is(skipped[0].Err, ErrTruncatedStream) {
+		t.Fatalf("Expected one truncated range, got %+v", skipped)
+	}
+}
@@ -0,0 +1,93 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+)
+
+// WithVerifyBeforeRelease makes Reader withhold all decompressed data until the
+// whole stream has been decoded and its integrity trailer (gzip CRC-32, zlib
+// Adler-32) has verified, so tampered or corrupted data is never released, not
+// even transiently. If the source implements io.Seeker the stream is decoded
+// twice (verify, then rewind and release); otherwise the decompressed data is
+// buffered in memory.
+func WithVerifyBeforeRelease() Option {
+	return func(m *Middleware) {
+		m.verifyBeforeRelease = true
+	}
+}
+
+// verifiedReader only releases data after the full stream has been verified
+type verifiedReader struct {
+	m   *Middleware
+	src io.Reader
+	r   io.Reader
+}
+
+func (r *verifiedReader) Read(p []byte) (n int, err error) {
+	if r.r == nil {
+		r.r = r.verify()
+	}
+	return r.r.Read(p)
+}
+
+// verify decodes the whole stream and returns a reader releasing the verified data
+func (r *verifiedReader) verify() io.Reader {
+	if seeker, ok := r.src.(io.Seeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			if err := r.drain(r.src, io.Discard); err != nil {
+				return &errReader{err}
+			}
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return &errReader{err}
+			}
+			dr, err := r.m.decode(r.src)
+			if err != nil {
+				return &errReader{err}
+			}
+			return dr
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := r.drain(r.src, &buf); err != nil {
+		return &errReader{err}
+	}
+	return &buf
+}
+
+// drain decodes src into dst, returning any decompression or checksum error
+func (r *verifiedReader) drain(src io.Reader, dst io.Writer) error {
+	dr, err := r.m.decode(src)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r.m.limitOutput(dr))
+	return err
+}
+
+// countingSeeker is a countingReader over a seekable source, keeping the
+// count in step with the source position across seeks
+type countingSeeker struct {
+	*countingReader
+	s io.Seeker
+}
+
+func (r *countingSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekCurrent {
+		pos, err := r.s.Seek(offset, whence)
+		if err == nil {
+			r.n += offset
+		}
+		return pos, err
+	}
+	cur, err := r.s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	pos, err := r.s.Seek(offset, whence)
+	if err == nil {
+		r.n += pos - cur
+	}
+	return pos, err
+}
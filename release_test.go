@@ -0,0 +1,82 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// sequentialReader hides any io.Seeker implementation of the wrapped reader
+type sequentialReader struct {
+	io.Reader
+}
+
+// seekCounter records the Seek calls and bytes read on a seekable source
+type seekCounter struct {
+	*bytes.Reader
+	seeks int
+	read  int
+}
+
+func (s *seekCounter) Read(p []byte) (int, error) {
+	n, err := s.Reader.Read(p)
+	s.read += n
+	return n, err
+}
+
+func (s *seekCounter) Seek(offset int64, whence int) (int64, error) {
+	s.seeks++
+	return s.Reader.Seek(offset, whence)
+}
+
+func TestVerifyBeforeRelease(t *testing.T) {
+	data := bytes.Repeat([]byte("verified payload "), 4096)
+
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		m := New(algorithm, WithVerifyBeforeRelease())
+		compressed := compressWith(t, m, data)
+
+		var seekable *seekCounter
+		sources := map[string]func([]byte) io.Reader{
+			"seekable": func(b []byte) io.Reader {
+				seekable = &seekCounter{Reader: bytes.NewReader(b)}
+				return seekable
+			},
+			"sequential": func(b []byte) io.Reader { return sequentialReader{bytes.NewReader(b)} },
+		}
+		for name, source := range sources {
+			got, err := io.ReadAll(m.Reader(source(compressed)))
+			if err != nil {
+				t.Fatalf("Algorithm %d, %s: Failed to read: %v", algorithm, name, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("Algorithm %d, %s: Data mismatch", algorithm, name)
+			}
+			if name == "seekable" {
+				// Verify, rewind and decode again instead of buffering the output
+				if seekable.seeks == 0 {
+					t.Fatalf("Algorithm %d: Expected the seekable source to be rewound", algorithm)
+				}
+				if seekable.read < 2*len(compressed) {
+					t.Fatalf("Algorithm %d: Expected the stream to be decoded twice, read %d of %d bytes", algorithm, seekable.read, 2*len(compressed))
+				}
+			}
+
+			// Corrupt the integrity trailer: no data may be released at all
+			corrupted := append([]byte(nil), compressed...)
+			trailer := len(corrupted) - 5
+			if algorithm == Zlib {
+				trailer = len(corrupted) - 1
+			}
+			corrupted[trailer] ^= 0xff
+
+			got, err = io.ReadAll(m.Reader(source(corrupted)))
+			if err == nil {
+				t.Fatalf("Algorithm %d, %s: Expected checksum error", algorithm, name)
+			}
+			if len(got) != 0 {
+				t.Fatalf("Algorithm %d, %s: Expected no released data, got %d bytes", algorithm, name, len(got))
+			}
+		}
+	}
+}
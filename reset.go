@@ -0,0 +1,320 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// deflateWriter is implemented by the gzip, zlib and flate writers
+type deflateWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// ResettableWriter is a compressing writer that can be reused for further
+// streams with Reset, keeping its deflate state allocated. It suits many
+// short-lived buffers, for example when kept in a sync.Pool.
+type ResettableWriter struct {
+	m          *Middleware
+	zw         deflateWriter
+	out        *countingWriter // compressed output
+	dst        io.Writer       // destination of the compressor, writing to out
+	n          int64           // uncompressed bytes accepted
+	flushed    int64           // value of n at the last flush
+	closed     bool
+	underlying io.Closer
+}
+
+// NewResettableWriter creates a ResettableWriter compressing into w. It
+// supports gzip, zlib and flate, including preset dictionaries, but no options
+// that add layers around the compressed data, such as WithStreamHeader or
+// WithArmor; those fail with ErrInvalidOption. WithMaxConcurrentStreams does
+// not apply to resettable writers.
+func (m *Middleware) NewResettableWriter(w io.Writer) (*ResettableWriter, error) {
+	if m.strictLevel && m.levelErr != nil {
+		return nil, m.report("open", m.levelErr)
+	}
+	if !m.resettableWriter() {
+		return nil, m.report("open", fmt.Errorf("%w: resettable writers support plain gzip, zlib and flate streams only", ErrInvalidOption))
+	}
+
+	rw := &ResettableWriter{m: m, underlying: m.underlying(w)}
+	rw.out = &countingWriter{w: m.watchWriter(w)}
+	rw.dst = m.buffering(m.retrying(rw.out))
+	dst := rw.dst
+	var err error
+	switch m.algorithm {
+	case Gzip:
+		var gw *gzip.Writer
+		if gw, err = gzip.NewWriterLevel(dst, m.level); err == nil {
+			gw.Header = m.gzipHeader()
+			rw.zw = gw
+		}
+	case Zlib:
+		rw.zw, err = zlib.NewWriterLevelDict(dst, m.level, m.dictionary)
+	case Flate:
+		rw.zw, err = flate.NewWriterDict(dst, m.level, m.dictionary)
+	}
+	if err != nil {
+		return nil, m.report("open", fmt.Errorf("failed to create %v writer: %w: %v", m.algorithm, ErrInvalidLevel, err))
+	}
+	return rw, nil
+}
+
+// resettableWriter reports whether streams consist of nothing but the output
+// of the standard library deflate writer
+func (m *Middleware) resettableWriter() bool {
+	switch m.algorithm {
+	case Gzip, Zlib, Flate:
+	default:
+		return false
+	}
+	return m.selector == nil && !m.autoLevel && m.dictName == "" && !m.streamHeader && m.seeds == nil &&
+		!m.deltaFilter && m.shuffleWidth == 0 && m.armor == ArmorNone && !m.omitEmpty && m.onNested == nil &&
+		len(m.paddingBuckets) == 0 && !m.seekableFormat && m.parallel == 0 && !m.headerCRC &&
+		!m.trustedFor(m.algorithm) && m.backend == BackendStdlib
+}
+
+// fail wraps err with the current stream offsets and reports it
+func (rw *ResettableWriter) fail(op string, err error) error {
+	return rw.m.report(op, rw.m.streamError(op, err, rw.out.n, rw.n))
+}
+
+func (rw *ResettableWriter) Write(p []byte) (int, error) {
+	if rw.closed {
+		return 0, rw.fail("write", ErrWriterClosed)
+	}
+	n, err := rw.zw.Write(p)
+	rw.n += int64(n)
+	if err != nil {
+		return n, rw.fail("write", err)
+	}
+	if rw.m.flushEvery > 0 && rw.n-rw.flushed >= int64(rw.m.flushEvery) {
+		return n, rw.Flush()
+	}
+	return n, nil
+}
+
+// Flush writes the data compressed so far through to the wrapped writer
+func (rw *ResettableWriter) Flush() error {
+	if rw.closed {
+		return rw.fail("flush", ErrWriterClosed)
+	}
+	rw.flushed = rw.n
+	err := rw.zw.Flush()
+	if err == nil {
+		err = flush(rw.dst)
+	}
+	return rw.fail("flush", err)
+}
+
+// Close completes the stream. Closing more than once is safe; later calls return nil.
+func (rw *ResettableWriter) Close() error {
+	if rw.closed {
+		return nil
+	}
+	rw.closed = true
+	err := rw.zw.Close()
+	if err == nil && rw.m.sizeTrailer() {
+		err = writeSizeTrailer(rw.dst, rw.n)
+	}
+	if err == nil {
+		err = drain(rw.dst)
+	}
+	return rw.fail("close", closeUnderlying(err, rw.underlying))
+}
+
+// Reset discards any unwritten state and starts a new stream into w, reusing
+// the compressor. Call Close first to complete the previous stream.
+func (rw *ResettableWriter) Reset(w io.Writer) {
+	rw.out = &countingWriter{w: rw.m.watchWriter(w)}
+	if b, ok := rw.dst.(*bufferedWriter); ok {
+		b.Reset(rw.m.retrying(rw.out))
+	} else {
+		rw.dst = rw.m.retrying(rw.out)
+	}
+	rw.zw.Reset(rw.dst)
+	if gw, ok := rw.zw.(*gzip.Writer); ok {
+		gw.Header = rw.m.gzipHeader()
+	}
+	rw.n, rw.flushed = 0, 0
+	rw.closed = false
+	rw.underlying = rw.m.underlying(w)
+}
+
+// ResettableReader is a decompressing reader that can be reused for further
+// streams with Reset, keeping its decoder state and buffers allocated. It suits
+// high-QPS read paths, for example when kept in a sync.Pool.
+type ResettableReader struct {
+	m          *Middleware
+	r          io.Reader       // stream passed to Reset
+	src        *countingReader // compressed input
+	br         *bufio.Reader
+	gz         *gzip.Reader
+	zr         io.ReadCloser // zlib or flate decoder
+	out        io.Reader     // decompressed stream with the size limit applied
+	n          int64         // decompressed bytes returned
+	err        error
+	underlying io.Closer
+}
+
+// NewResettableReader creates a ResettableReader decompressing r. Like
+// NewReader, it reports errors found while opening the stream. It supports
+// gzip, zlib and flate streams without layers around the compressed data;
+// options adding or requiring such layers, such as WithStreamHeader,
+// WithStrictTrailer or WithCorruptionRecovery, fail with ErrInvalidOption.
+// WithMaxConcurrentStreams does not apply to resettable readers.
+func (m *Middleware) NewResettableReader(r io.Reader) (*ResettableReader, error) {
+	if !m.resettableReader() {
+		return nil, m.report("open", fmt.Errorf("%w: resettable readers support plain gzip, zlib and flate streams only", ErrInvalidOption))
+	}
+	rr := &ResettableReader{m: m}
+	if err := rr.Reset(r); err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+// resettableReader reports whether streams are decoded by nothing but the
+// standard library deflate readers
+func (m *Middleware) resettableReader() bool {
+	switch m.algorithm {
+	case Gzip, Zlib, Flate:
+	default:
+		return false
+	}
+	return m.selector == nil && m.dictName == "" && !m.streamHeader && m.seeds == nil &&
+		!m.deltaFilter && m.shuffleWidth == 0 && m.armor == ArmorNone && !m.omitEmpty && m.maxNesting == 0 &&
+		m.onSkip == nil && !m.strictTrailer && m.headerLimits == nil && !m.verifyBeforeRelease &&
+		!m.uncheckedFor(m.algorithm) && m.readProgress == nil
+}
+
+// Reset starts decompressing r, reusing the decoder. The stream header is
+// read immediately; errors opening the stream are returned and also from Read.
+func (rr *ResettableReader) Reset(r io.Reader) error {
+	m := rr.m
+	rr.r = r
+	rr.src = &countingReader{r: m.limitInput(m.watchReader(m.readBuffering(r)))}
+	if rr.br == nil {
+		rr.br = bufio.NewReader(rr.src)
+	} else {
+		rr.br.Reset(rr.src)
+	}
+	rr.out = m.limitOutput(resettableStream{rr})
+	rr.n = 0
+	rr.underlying = m.underlying(r)
+
+	rr.err = nil
+	if err := rr.open(); err != nil {
+		rr.err = m.report("open", m.streamError("open", classify(err), rr.src.n, 0))
+	}
+	return rr.err
+}
+
+// open resets the decoder to the stream at the current position
+func (rr *ResettableReader) open() error {
+	var err error
+	switch rr.m.algorithm {
+	case Gzip:
+		if rr.gz == nil {
+			rr.gz, err = gzip.NewReader(rr.br)
+		} else {
+			err = rr.gz.Reset(rr.br)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create gzip reader: %w", noEOF(err))
+		}
+		rr.gz.Multistream(!rr.m.singleStream)
+	case Zlib:
+		if rr.zr == nil {
+			rr.zr, err = zlib.NewReaderDict(rr.br, rr.m.dictionary)
+		} else {
+			err = rr.zr.(zlib.Resetter).Reset(rr.br, rr.m.dictionary)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create zlib reader: %w", err)
+		}
+	case Flate:
+		if rr.zr == nil {
+			rr.zr = flate.NewReaderDict(rr.br, rr.m.dictionary)
+		} else {
+			err = rr.zr.(flate.Resetter).Reset(rr.br, rr.m.dictionary)
+		}
+	}
+	return err
+}
+
+func (rr *ResettableReader) Read(p []byte) (int, error) {
+	if rr.err != nil {
+		return 0, rr.err
+	}
+	n, err := rr.out.Read(p)
+	rr.n += int64(n)
+	if err != nil && err != io.EOF {
+		rr.err = rr.m.report("read", rr.m.streamError("read", classify(err), rr.src.n, rr.n))
+		return n, rr.err
+	}
+	return n, err
+}
+
+func (rr *ResettableReader) Header() (*gzip.Header, bool) {
+	if rr.gz == nil || rr.m.algorithm != Gzip {
+		return nil, false
+	}
+	return &rr.gz.Header, true
+}
+
+func (rr *ResettableReader) ExpectedSize() (int64, bool) {
+	return rr.m.expectedSize(rr.r, rr)
+}
+
+// Close releases the stream. The reader can be reused with Reset afterwards.
+func (rr *ResettableReader) Close() error {
+	var err error
+	if rr.gz != nil && rr.m.algorithm == Gzip {
+		err = rr.gz.Close()
+	} else if rr.zr != nil {
+		err = rr.zr.Close()
+	}
+	return rr.m.report("close", closeUnderlying(err, rr.underlying))
+}
+
+// resettableStream reads the decompressed stream of a ResettableReader,
+// continuing with back-to-back zlib streams like Reader
+type resettableStream struct {
+	rr *ResettableReader
+}
+
+func (s resettableStream) Read(p []byte) (int, error) {
+	rr := s.rr
+	if rr.m.algorithm == Gzip {
+		return rr.gz.Read(p)
+	}
+	for {
+		n, err := rr.zr.Read(p)
+		if err == io.EOF && rr.m.sizeTrailer() {
+			if terr := consumeSizeTrailer(rr.br, rr.n+int64(n)); terr != nil {
+				return n, terr
+			}
+			return n, err
+		}
+		if err != io.EOF || rr.m.algorithm != Zlib || rr.m.singleStream {
+			return n, err
+		}
+		hdr, _ := rr.br.Peek(2)
+		if len(hdr) < 2 || hdr[0]&0x0f != 8 || (uint16(hdr[0])<<8|uint16(hdr[1]))%31 != 0 {
+			return n, io.EOF
+		}
+		if err := rr.zr.(zlib.Resetter).Reset(rr.br, rr.m.dictionary); err != nil {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestResettableWriter(t *testing.T) {
+	dict := []byte("shared dictionary content")
+	for _, m := range []*Middleware{New(Gzip), New(Zlib, WithDictionary(dict)), New(Flate, WithLevel(1))} {
+		var first bytes.Buffer
+		rw, err := m.NewResettableWriter(&first)
+		if err != nil {
+			t.Fatalf("%v: Failed to create writer: %v", m.algorithm, err)
+		}
+
+		for i, out := range []*bytes.Buffer{&first, {}, {}} {
+			if i > 0 {
+				rw.Reset(out)
+			}
+			data := bytes.Repeat([]byte{'a' + byte(i)}, 1000*(i+1))
+			if _, err := rw.Write(data); err != nil {
+				t.Fatalf("%v: Failed to write stream %d: %v", m.algorithm, i, err)
+			}
+			if err := rw.Close(); err != nil {
+				t.Fatalf("%v: Failed to close stream %d: %v", m.algorithm, i, err)
+			}
+
+			got, err := io.ReadAll(m.Reader(out))
+			if err != nil {
+				t.Fatalf("%v: Failed to read stream %d: %v", m.algorithm, i, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%v: Data mismatch in stream %d", m.algorithm, i)
+			}
+		}
+
+		if _, err := rw.Write([]byte("late")); !errors.Is(err, ErrWriterClosed) {
+			t.Fatalf("%v: Expected ErrWriterClosed, got %v", m.algorithm, err)
+		}
+	}
+}
+
+func TestResettableWriter_Unsupported(t *testing.T) {
+	for _, m := range []*Middleware{New(LZW), New(Gzip, WithStreamHeader()), NewLazy(), New(Gzip, WithParallel(4))} {
+		if _, err := m.NewResettableWriter(io.Discard); !errors.Is(err, ErrInvalidOption) {
+			t.Fatalf("%v: Expected ErrInvalidOption, got %v", m.algorithm, err)
+		}
+	}
+}
+
+func TestResettableReader(t *testing.T) {
+	dict := []byte("shared dictionary content")
+	for _, m := range []*Middleware{New(Gzip), New(Zlib, WithDictionary(dict)), New(Flate)} {
+		var streams [][]byte
+		var inputs [][]byte
+		for i := range 3 {
+			data := bytes.Repeat([]byte{'a' + byte(i)}, 1000*(i+1))
+			inputs = append(inputs, data)
+			streams = append(streams, compressWith(t, m, data))
+		}
+
+		rr, err := m.NewResettableReader(bytes.NewReader(streams[0]))
+		if err != nil {
+			t.Fatalf("%v: Failed to create reader: %v", m.algorithm, err)
+		}
+		for i, stream := range streams {
+			if i > 0 {
+				if err := rr.Reset(bytes.NewReader(stream)); err != nil {
+					t.Fatalf("%v: Failed to reset for stream %d: %v", m.algorithm, i, err)
+				}
+			}
+			got, err := io.ReadAll(rr)
+			if err != nil {
+				t.Fatalf("%v: Failed to read stream %d: %v", m.algorithm, i, err)
+			}
+			if !bytes.Equal(got, inputs[i]) {
+				t.Fatalf("%v: Data mismatch in stream %d", m.algorithm, i)
+			}
+			if err := rr.Close(); err != nil {
+				t.Fatalf("%v: Failed to close stream %d: %v", m.algorithm, i, err)
+			}
+		}
+
+		// A damaged stream fails, and the reader recovers on Reset
+		truncated := streams[2][:len(streams[2])/2]
+		rr.Reset(bytes.NewReader(truncated))
+		if _, err := io.ReadAll(rr); !errors.Is(err, ErrTruncatedStream) {
+			t.Fatalf("%v: Expected ErrTruncatedStream, got %v", m.algorithm, err)
+		}
+		if err := rr.Reset(bytes.NewReader(streams[0])); err != nil {
+			t.Fatalf("%v: Failed to reset after error: %v", m.algorithm, err)
+		}
+		if got, err := io.ReadAll(rr); err != nil || !bytes.Equal(got, inputs[0]) {
+			t.Fatalf("%v: Failed to read after error: %v", m.algorithm, err)
+		}
+	}
+}
+
+func TestResettableReader_Multistream(t *testing.T) {
+	m := New(Zlib)
+	stream := append(compressWith(t, m, []byte("first ")), compressWith(t, m, []byte("second"))...)
+
+	rr, err := m.NewResettableReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	got, err := io.ReadAll(rr)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if string(got) != "first second" {
+		t.Fatalf("Expected %q, got %q", "first second", got)
+	}
+}
+
+func TestResettableReader_Errors(t *testing.T) {
+	if _, err := New(Gzip, WithStrictTrailer()).NewResettableReader(bytes.NewReader(nil)); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Expected ErrInvalidOption, got %v", err)
+	}
+	var ce *CompressionError
+	if _, err := New(Gzip).NewResettableReader(bytes.NewReader([]byte("not a gzip stream at all"))); !errors.As(err, &ce) || ce.Op != "open" {
+		t.Fatalf("Expected open CompressionError, got %v", err)
+	}
+}
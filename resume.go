@@ -0,0 +1,182 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidCheckpoint is returned when a checkpoint cannot be resumed with the middleware
+var ErrInvalidCheckpoint = errors.New("invalid checkpoint")
+
+// Checkpoint records the progress of a ResumableReader. Gzip members and zlib
+// streams are independent, so decoding resumes at the start of the member
+// being read and skips the data already returned from it. Multi-member output,
+// such as written with WithParallel or WithSeekableFormat, therefore resumes
+// cheaply; other streams are decoded again from their start.
+type Checkpoint struct {
+	// CompressedOffset is the position of the current member in the compressed input
+	CompressedOffset int64 `json:"compressed_offset"`
+	// MemberOffset is the decompressed position at which the current member starts
+	MemberOffset int64 `json:"member_offset"`
+	// DecompressedOffset is the number of decompressed bytes returned so far
+	DecompressedOffset int64 `json:"decompressed_offset"`
+}
+
+// ResumableReader decompresses a stream while tracking a Checkpoint from which
+// decoding can continue later, for example after a restart
+type ResumableReader struct {
+	m   *Middleware
+	src *countingReader
+	br  *bufio.Reader
+	cp  Checkpoint
+
+	base   int64     // compressed offset at which src starts
+	member io.Reader // decoder of the current member
+	opened bool      // whether a member has been opened
+	skip   int64     // decompressed bytes of the member to discard
+	err    error
+}
+
+// NewResumableReader continues decompressing at cp; the zero Checkpoint starts
+// at the beginning. r must be positioned at cp.CompressedOffset of the
+// compressed input, for example by seeking a file or with a range request.
+func (m *Middleware) NewResumableReader(r io.Reader, cp Checkpoint) (*ResumableReader, error) {
+	if cp.CompressedOffset < 0 || cp.MemberOffset < 0 || cp.DecompressedOffset < cp.MemberOffset {
+		return nil, fmt.Errorf("%w: %+v", ErrInvalidCheckpoint, cp)
+	}
+	if !m.memberwise() && cp.CompressedOffset != 0 {
+		return nil, fmt.Errorf("%w: %v streams resume from offset 0", ErrInvalidCheckpoint, m.algorithm)
+	}
+	r = m.readBuffering(r)
+	size := 4096
+	if m.memberwise() {
+		// Members are decoded directly, so the source gets the guards Reader
+		// adds; otherwise Reader adds them itself
+		r = m.limitInput(m.watchReader(r))
+		if m.headerLimits != nil {
+			size = max(size, m.headerLimits.bufferSize())
+		}
+	}
+	src := &countingReader{r: r}
+	skip := cp.DecompressedOffset - cp.MemberOffset
+	// The skipped data is counted again while it is discarded
+	cp.DecompressedOffset = cp.MemberOffset
+	return &ResumableReader{
+		m:    m,
+		src:  src,
+		br:   bufio.NewReaderSize(src, size),
+		cp:   cp,
+		base: cp.CompressedOffset,
+		skip: skip,
+	}, nil
+}
+
+// memberwise reports whether streams consist of independent members that are
+// decoded one by one. Layers spanning members (filters, stream headers, seeds)
+// require decoding from the start, as do trusted pipeline streams, whose
+// reader buffers ahead of the member end.
+func (m *Middleware) memberwise() bool {
+	if m.algorithm != Gzip && m.algorithm != Zlib || m.uncheckedFor(m.algorithm) {
+		return false
+	}
+	return m.selector == nil && m.dictName == "" && !m.streamHeader && m.seeds == nil &&
+		!m.deltaFilter && m.shuffleWidth == 0 && m.maxNesting == 0 && m.armor == ArmorNone
+}
+
+// Checkpoint returns the current progress. It is only consistent between Read calls.
+func (r *ResumableReader) Checkpoint() Checkpoint {
+	return r.cp
+}
+
+// offset returns the position of the next unread byte in the compressed input
+func (r *ResumableReader) offset() int64 {
+	return r.base + r.src.n - int64(r.br.Buffered())
+}
+
+func (r *ResumableReader) Read(p []byte) (int, error) {
+	for r.err == nil {
+		if r.member == nil {
+			r.err = r.next()
+			continue
+		}
+		if r.skip > 0 {
+			n, err := io.CopyN(io.Discard, r.member, r.skip)
+			r.skip -= n
+			r.cp.DecompressedOffset += n
+			if err != nil {
+				r.err = fmt.Errorf("%w: member ends before the checkpoint: %w", ErrInvalidCheckpoint, noEOF(err))
+			}
+			continue
+		}
+		n, err := r.member.Read(p)
+		r.cp.DecompressedOffset += int64(n)
+		if limit := r.m.maxDecompressed; limit > 0 && r.m.memberwise() && r.cp.DecompressedOffset > limit {
+			// As with Reader, the data up to the limit is returned
+			excess := int(min(r.cp.DecompressedOffset-limit, int64(n)))
+			n -= excess
+			r.cp.DecompressedOffset -= int64(excess)
+			r.err = fmt.Errorf("%w: more than %d bytes", ErrDecompressedTooLarge, limit)
+			return n, r.err
+		}
+		switch {
+		case err == io.EOF && r.m.memberwise():
+			// The decoder stops exactly at the member end, where the next member starts
+			r.member = nil
+			r.cp.CompressedOffset = r.offset()
+			r.cp.MemberOffset = r.cp.DecompressedOffset
+		case err != nil:
+			r.err = classify(err)
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+	return 0, r.err
+}
+
+// next opens the member at the current position
+func (r *ResumableReader) next() error {
+	if !r.m.memberwise() {
+		dr := r.m.reader(r.br)
+		if er, ok := dr.(*errReader); ok {
+			return er.err
+		}
+		r.member = dr
+		return nil
+	}
+
+	hdr, err := r.br.Peek(2)
+	if len(hdr) == 0 && err == io.EOF {
+		return io.EOF
+	}
+	first := !r.opened
+	r.opened = true
+	switch r.m.algorithm {
+	case Gzip:
+		if r.m.headerLimits != nil {
+			if _, err := parseGzipHeader(r.br, *r.m.headerLimits); err != nil {
+				return err
+			}
+		}
+		zr, err := gzip.NewReader(r.br)
+		if err != nil {
+			return classify(fmt.Errorf("failed to create gzip reader: %w", err))
+		}
+		zr.Multistream(false)
+		r.member = zr
+	case Zlib:
+		// Like multistream zlib reading, data that is not a zlib stream ends the input
+		if !first && (len(hdr) < 2 || hdr[0]&0x0f != 8 || (uint16(hdr[0])<<8|uint16(hdr[1]))%31 != 0) {
+			return io.EOF
+		}
+		zr, err := r.m.zlibStream(r.br)
+		if err != nil {
+			return classify(fmt.Errorf("failed to create zlib reader: %w", err))
+		}
+		r.member = zr
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readResumed reads data through a ResumableReader, stopping after stopAfter
+// bytes and resuming from the checkpoint, until the stream ends
+func readResumed(t *testing.T, m *Middleware, compressed []byte, stopAfter int) ([]byte, []Checkpoint) {
+	t.Helper()
+	var out []byte
+	var checkpoints []Checkpoint
+	var cp Checkpoint
+	for {
+		rr, err := m.NewResumableReader(bytes.NewReader(compressed[cp.CompressedOffset:]), cp)
+		if err != nil {
+			t.Fatalf("Failed to resume at %+v: %v", cp, err)
+		}
+		buf := make([]byte, 1000)
+		n := 0
+		for n < stopAfter {
+			k, err := rr.Read(buf)
+			out = append(out, buf[:k]...)
+			n += k
+			if err == io.EOF {
+				return out, checkpoints
+			}
+			if err != nil {
+				t.Fatalf("Failed to read: %v", err)
+			}
+		}
+		cp = rr.Checkpoint()
+		checkpoints = append(checkpoints, cp)
+	}
+}
+
+func TestResumableReader(t *testing.T) {
+	data := make([]byte, 3*parallelBlockSize+5000)
+	for i := range data {
+		data[i] = byte(i / 3)
+	}
+
+	tests := []struct {
+		name       string
+		m          *Middleware
+		memberwise bool
+	}{
+		{"parallel gzip", New(Gzip, WithParallel(2)), true},
+		{"single gzip", New(Gzip), true},
+		{"zlib", New(Zlib), true},
+		{"flate", New(Flate), false},
+	}
+	for _, tt := range tests {
+		compressed := compressWith(t, tt.m, data)
+		if tt.name == "zlib" {
+			// Concatenated zlib streams are independent as well
+			compressed = append(compressWith(t, tt.m, data[:100000]), compressWith(t, tt.m, data[100000:])...)
+		}
+
+		got, checkpoints := readResumed(t, tt.m, compressed, 700000)
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%s: Data mismatch after resuming", tt.name)
+		}
+		if len(checkpoints) == 0 {
+			t.Fatalf("%s: Expected checkpoints", tt.name)
+		}
+		for _, cp := range checkpoints {
+			if cp.CompressedOffset != 0 && !tt.memberwise {
+				t.Fatalf("%s: Expected checkpoints at offset 0, got %+v", tt.name, cp)
+			}
+		}
+		if tt.name == "parallel gzip" && checkpoints[len(checkpoints)-1].CompressedOffset == 0 {
+			t.Fatalf("%s: Expected checkpoints at member boundaries, got %+v", tt.name, checkpoints)
+		}
+	}
+}
+
+func TestResumableReader_InvalidCheckpoint(t *testing.T) {
+	if _, err := New(Flate).NewResumableReader(bytes.NewReader(nil), Checkpoint{CompressedOffset: 10}); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Fatalf("Expected ErrInvalidCheckpoint, got %v", err)
+	}
+
+	compressed := compressWith(t, New(Gzip), []byte("short"))
+	rr, err := New(Gzip).NewResumableReader(bytes.NewReader(compressed), Checkpoint{DecompressedOffset: 100})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	if _, err := io.ReadAll(rr); !errors.Is(err, ErrInvalidCheckpoint) {
+		t.Fatalf("Expected ErrInvalidCheckpoint, got %v", err)
+	}
+}
+
+func TestResumableReader_Limits(t *testing.T) {
+	data := bytes.Repeat([]byte("resumable "), 1<<20)
+
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		compressed := compressWith(t, New(algorithm), data)
+
+		rr, err := New(algorithm, WithMaxDecompressedSize(1<<20)).NewResumableReader(bytes.NewReader(compressed), Checkpoint{})
+		if err != nil {
+			t.Fatalf("%v: Failed to create reader: %v", algorithm, err)
+		}
+		got, err := io.ReadAll(rr)
+		if !errors.Is(err, ErrDecompressedTooLarge) || len(got) != 1<<20 {
+			t.Fatalf("%v: Expected ErrDecompressedTooLarge after 1MB, got %d bytes, %v", algorithm, len(got), err)
+		}
+
+		rr, err = New(algorithm, WithMaxCompressedInput(100)).NewResumableReader(bytes.NewReader(compressed), Checkpoint{})
+		if err != nil {
+			t.Fatalf("%v: Failed to create reader: %v", algorithm, err)
+		}
+		if _, err := io.ReadAll(rr); !errors.Is(err, ErrCompressedTooLarge) {
+			t.Fatalf("%v: Expected ErrCompressedTooLarge, got %v", algorithm, err)
+		}
+	}
+
+	// Header limits apply to every member
+	m := New(Gzip, WithHeaderLimits(16, 16, 16))
+	long := gzipWithHeader(t, gzip.Header{Name: strings.Repeat("n", 100)}, []byte("second"))
+	compressed := append(compressWith(t, m, []byte("first")), long...)
+	rr, err := m.NewResumableReader(bytes.NewReader(compressed), Checkpoint{})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	if _, err := io.ReadAll(rr); !errors.Is(err, ErrHeaderTooLarge) {
+		t.Fatalf("Expected ErrHeaderTooLarge, got %v", err)
+	}
+}
+
+func TestResumableReader_Trusted(t *testing.T) {
+	data := bytes.Repeat([]byte("trusted resumable "), 10000)
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		for _, opt := range []Option{WithTrustedPipeline(), WithSkipChecksum()} {
+			m := New(algorithm, opt)
+			got, _ := readResumed(t, m, compressWith(t, m, data), 50000)
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%v: Data mismatch after resuming", algorithm)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package compressionstdlib
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls retries of failed writes to the wrapped writer
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per write, including the first
+	MaxAttempts int
+	// Backoff is the delay before the first retry; it doubles after each retry
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries; zero means no cap
+	MaxBackoff time.Duration
+	// Retryable reports whether a write error is transient. If nil, errors
+	// reporting Temporary() and syscall.EAGAIN are retried.
+	Retryable func(error) bool
+}
+
+// WithWriteRetry retries writes to the wrapped writer that fail with a
+// transient error, so a single EAGAIN-style failure does not abort the whole
+// compressed stream. Bytes accepted before the failure are not written again.
+// Policies with fewer than two attempts disable retries.
+func WithWriteRetry(policy RetryPolicy) Option {
+	return func(m *Middleware) {
+		if policy.MaxAttempts >= 2 {
+			m.retry = &policy
+		} else {
+			m.retry = nil
+		}
+	}
+}
+
+// retrying wraps w with the configured retry policy
+func (m *Middleware) retrying(w io.Writer) io.Writer {
+	if m.retry == nil {
+		return w
+	}
+	return &retryWriter{w: w, policy: *m.retry}
+}
+
+// retryWriter retries transient write errors of the wrapped writer
+type retryWriter struct {
+	w      io.Writer
+	policy RetryPolicy
+}
+
+func (w *retryWriter) Write(p []byte) (int, error) {
+	written := 0
+	backoff := w.policy.Backoff
+	for attempt := 1; ; attempt++ {
+		n, err := w.w.Write(p[written:])
+		written += n
+		if written == len(p) {
+			return written, nil
+		}
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+		if attempt >= w.policy.MaxAttempts || !w.retryable(err) {
+			return written, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if w.policy.MaxBackoff > 0 && backoff > w.policy.MaxBackoff {
+			backoff = w.policy.MaxBackoff
+		}
+	}
+}
+
+func (w *retryWriter) retryable(err error) bool {
+	// A stalled stream stays failed
+	if errors.Is(err, ErrStalled) {
+		return false
+	}
+	if w.policy.Retryable != nil {
+		return w.policy.Retryable(err)
+	}
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+	return errors.Is(err, syscall.EAGAIN) || err == io.ErrShortWrite
+}
+
+func (w *retryWriter) Flush() error {
+	return flush(w.w)
+}
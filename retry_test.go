@@ -0,0 +1,80 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"syscall"
+	"testing"
+)
+
+// flakyWriter accepts half of every other write and fails the others with err
+type flakyWriter struct {
+	bytes.Buffer
+	err    error
+	calls  int
+	failed int
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.calls%2 == 1 {
+		w.failed++
+		n, _ := w.Buffer.Write(p[:len(p)/2])
+		return n, w.err
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestWriteRetry(t *testing.T) {
+	data := bytes.Repeat([]byte("retry transient errors "), 5000)
+	m := New(Gzip, WithWriteRetry(RetryPolicy{MaxAttempts: 3}))
+
+	dst := &flakyWriter{err: syscall.EAGAIN}
+	w := m.Writer(dst).(io.WriteCloser)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if dst.failed == 0 {
+		t.Fatal("Expected transient failures")
+	}
+
+	got, err := io.ReadAll(m.Reader(&dst.Buffer))
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestWriteRetry_Permanent(t *testing.T) {
+	permanent := errors.New("permission denied")
+	dst := &flakyWriter{err: permanent}
+	w := New(Gzip, WithWriteRetry(RetryPolicy{MaxAttempts: 5})).Writer(dst).(io.WriteCloser)
+	w.Write(bytes.Repeat([]byte("x"), 100))
+	if err := w.Close(); !errors.Is(err, permanent) {
+		t.Fatalf("Expected permanent error, got %v", err)
+	}
+	if dst.calls != 1 {
+		t.Fatalf("Expected no retries for permanent errors, got %d calls", dst.calls)
+	}
+}
+
+func TestWriteRetry_Exhausted(t *testing.T) {
+	calls := 0
+	policy := RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool {
+		calls++
+		return true
+	}}
+	w := New(None, WithWriteRetry(policy)).Writer(failingWriter{}).(io.WriteCloser)
+	if _, err := w.Write([]byte("data")); err == nil {
+		t.Fatal("Expected error once attempts are exhausted")
+	}
+	if calls != 2 {
+		t.Fatalf("Expected 2 retries, got %d", calls)
+	}
+}
@@ -0,0 +1,139 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"io"
+)
+
+// rleBufferSize is the amount of input collected before the RLE writer encodes it
+const rleBufferSize = 4 * 1024
+
+// rleEncode appends src to dst in PackBits encoding: a header byte n of 0-127
+// is followed by n+1 literal bytes, a header of 129-255 by a single byte
+// repeated 257-n times
+func rleEncode(dst, src []byte) []byte {
+	for len(src) > 0 {
+		run := 1
+		for run < len(src) && run < 128 && src[run] == src[0] {
+			run++
+		}
+		if run >= 3 {
+			dst = append(dst, byte(257-run), src[0])
+			src = src[run:]
+			continue
+		}
+
+		// Literals extend up to the next run of at least three bytes
+		n := 1
+		for n < len(src) && n < 128 {
+			if n+2 < len(src) && src[n] == src[n+1] && src[n] == src[n+2] {
+				break
+			}
+			n++
+		}
+		dst = append(dst, byte(n-1))
+		dst = append(dst, src[:n]...)
+		src = src[n:]
+	}
+	return dst
+}
+
+// rleWriter run-length encodes the data written to it. Close writes any
+// buffered data but does not close the underlying writer.
+type rleWriter struct {
+	w       io.Writer
+	pending []byte
+	out     []byte
+}
+
+func (w *rleWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	if len(w.pending) < rleBufferSize {
+		return len(p), nil
+	}
+
+	// Keep the incomplete part of the trailing run, it may continue in the next write
+	start := len(w.pending) - 1
+	for start > 0 && w.pending[start-1] == w.pending[start] {
+		start--
+	}
+	cut := len(w.pending) - (len(w.pending)-start)%128
+	if err := w.encode(cut); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// encode writes the first n pending bytes
+func (w *rleWriter) encode(n int) error {
+	if n == 0 {
+		return nil
+	}
+	w.out = rleEncode(w.out[:0], w.pending[:n])
+	w.pending = append(w.pending[:0], w.pending[n:]...)
+	_, err := w.w.Write(w.out)
+	return err
+}
+
+func (w *rleWriter) Flush() error {
+	if err := w.encode(len(w.pending)); err != nil {
+		return err
+	}
+	return flush(w.w)
+}
+
+func (w *rleWriter) Close() error {
+	return w.encode(len(w.pending))
+}
+
+// rleReader decodes PackBits run-length encoded data
+type rleReader struct {
+	r       *bufio.Reader
+	literal int  // literal bytes left in the current packet
+	run     int  // repetitions left in the current packet
+	value   byte // repeated byte
+}
+
+func newRLEReader(r io.Reader) *rleReader {
+	return &rleReader{r: bufio.NewReader(r)}
+}
+
+func (r *rleReader) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		switch {
+		case r.literal > 0:
+			m, err := r.r.Read(p[n:min(len(p), n+r.literal)])
+			n += m
+			r.literal -= m
+			if err != nil {
+				return n, noEOF(err)
+			}
+		case r.run > 0:
+			m := min(len(p)-n, r.run)
+			for i := range m {
+				p[n+i] = r.value
+			}
+			n += m
+			r.run -= m
+		default:
+			if n > 0 && r.r.Buffered() == 0 {
+				// Return what is decoded instead of blocking on the next packet
+				return n, nil
+			}
+			header, err := r.r.ReadByte()
+			if err != nil {
+				return n, err
+			}
+			switch {
+			case header < 128:
+				r.literal = int(header) + 1
+			case header > 128:
+				if r.value, err = r.r.ReadByte(); err != nil {
+					return n, noEOF(err)
+				}
+				r.run = 257 - int(header)
+			}
+		}
+	}
+	return n, nil
+}
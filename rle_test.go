@@ -0,0 +1,86 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestRLERoundTrip(t *testing.T) {
+	noise := make([]byte, 5000)
+	rand.New(rand.NewSource(1)).Read(noise)
+
+	var telemetry []byte
+	for i := 0; i < 200; i++ {
+		telemetry = append(telemetry, bytes.Repeat([]byte{byte(i % 4)}, 300)...)
+		telemetry = append(telemetry, 'x', 'y')
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"single", []byte{7}},
+		{"pair", []byte{7, 7}},
+		{"run", bytes.Repeat([]byte{0}, 10000)},
+		{"noise", noise},
+		{"telemetry", telemetry},
+	}
+
+	m := New(RLE)
+	for _, tt := range tests {
+		compressed := compressWith(t, m, tt.data)
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%s: Failed to read: %v", tt.name, err)
+		}
+		if !bytes.Equal(got, tt.data) {
+			t.Fatalf("%s: Data mismatch", tt.name)
+		}
+	}
+
+	if compressed := compressWith(t, m, telemetry); len(compressed)*10 > len(telemetry) {
+		t.Fatalf("Expected repetitive data to shrink at least 10x, got %d of %d bytes", len(compressed), len(telemetry))
+	}
+}
+
+func TestRLERunAcrossWrites(t *testing.T) {
+	var buf bytes.Buffer
+	w := New(RLE).Writer(&buf)
+	for i := 0; i < 100; i++ {
+		if _, err := w.Write(bytes.Repeat([]byte{'a'}, 100)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+	if err := w.(io.Closer).Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	// 10000 bytes in runs of at most 128, two bytes per run
+	if want := (10000 + 127) / 128 * 2; buf.Len() != want {
+		t.Fatalf("Expected %d encoded bytes, got %d", want, buf.Len())
+	}
+}
+
+func TestRLETruncated(t *testing.T) {
+	// Literal packet announcing four bytes with only two present
+	_, err := io.ReadAll(New(RLE).Reader(bytes.NewReader([]byte{3, 'a', 'b'})))
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.Is(err, ErrTruncatedStream) {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestRLEBoundedBuffer(t *testing.T) {
+	w := &rleWriter{w: io.Discard}
+	for i := 0; i < 100; i++ {
+		if _, err := w.Write(bytes.Repeat([]byte{'z'}, 1000)); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+	if len(w.pending) > rleBufferSize {
+		t.Fatalf("Expected bounded buffer for a long run, got %d pending bytes", len(w.pending))
+	}
+}
@@ -0,0 +1,29 @@
+//go:build s2
+
+package compressionstdlib
+
+import (
+	"io"
+	"runtime"
+
+	"github.com/klauspost/compress/s2"
+)
+
+func init() {
+	registerCodec(S2, codec{
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			// Blocks are compressed concurrently, one goroutine per available CPU
+			opts := []s2.WriterOption{s2.WriterConcurrency(runtime.GOMAXPROCS(0))}
+			switch {
+			case level >= 8:
+				opts = append(opts, s2.WriterBestCompression())
+			case level >= 4:
+				opts = append(opts, s2.WriterBetterCompression())
+			}
+			return s2.NewWriter(w, opts...), nil
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(s2.NewReader(r)), nil
+		},
+	})
+}
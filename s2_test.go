@@ -0,0 +1,30 @@
+//go:build s2
+
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestS2RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("s2 backend "), 50000)
+	for _, level := range []int{1, 5, 9} {
+		m, err := NewE(S2, WithLevel(level))
+		if err != nil {
+			t.Fatalf("Level %d: %v", level, err)
+		}
+		compressed := compressWith(t, m, data)
+		if len(compressed) >= len(data)/10 {
+			t.Fatalf("Level %d: expected compression, got %d bytes for %d", level, len(compressed), len(data))
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("Level %d: read failed: %v", level, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Level %d: data mismatch", level)
+		}
+	}
+}
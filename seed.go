@@ -0,0 +1,161 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/adler32"
+	"io"
+	"sync"
+)
+
+// DefaultSeedSize is the amount of trailing data used to seed the next segment, matching the deflate window
+const DefaultSeedSize = 32 * 1024
+
+// DictionaryStore persists segment seeds by their zlib dictionary ID (the Adler-32
+// of the seed), so segments remain independently decodable
+type DictionaryStore interface {
+	Put(id uint32, dict []byte) error
+	Get(id uint32) ([]byte, bool)
+}
+
+// segmentSeeds holds the seeds carried from one segment to the next
+type segmentSeeds struct {
+	mu    sync.Mutex
+	write []byte
+	read  []byte
+}
+
+// WithSegmentSeeding seeds the preset dictionary of each new zlib stream with
+// the last size bytes of the previous stream written through this middleware,
+// recovering the ratio lost when rotated segments reset the compressor. Readers
+// resolve the seed from the previously read segment or, if configured, from the
+// DictionaryStore. A size <= 0 uses DefaultSeedSize. Only zlib streams are
+// seeded, as gzip has no preset dictionary support.
+func WithSegmentSeeding(size int) Option {
+	return func(m *Middleware) {
+		if size <= 0 || size > DefaultSeedSize {
+			size = DefaultSeedSize
+		}
+		m.seedSize = size
+		m.seeds = &segmentSeeds{}
+	}
+}
+
+// WithDictionaryStore persists segment seeds in store, so any segment can be
+// decoded without reading the segment before it first
+func WithDictionaryStore(store DictionaryStore) Option {
+	return func(m *Middleware) {
+		m.dictStore = store
+	}
+}
+
+// writeSeed returns the dictionary for the next zlib stream
+func (m *Middleware) writeSeed() []byte {
+	if m.seeds == nil {
+		return m.dictionary
+	}
+	m.seeds.mu.Lock()
+	defer m.seeds.mu.Unlock()
+	return m.seeds.write
+}
+
+// zlibStream opens the zlib stream at the start of br, resolving seeded dictionaries
+func (m *Middleware) zlibStream(br *bufio.Reader) (io.ReadCloser, error) {
+	if m.seeds == nil {
+		return zlib.NewReaderDict(br, m.dictionary)
+	}
+
+	var dict []byte
+	if hdr, _ := br.Peek(6); len(hdr) == 6 && hdr[1]&0x20 != 0 {
+		id := binary.BigEndian.Uint32(hdr[2:])
+		m.seeds.mu.Lock()
+		if adler32.Checksum(m.seeds.read) == id {
+			dict = m.seeds.read
+		}
+		m.seeds.mu.Unlock()
+		if dict == nil && m.dictStore != nil {
+			dict, _ = m.dictStore.Get(id)
+		}
+	}
+	return zlib.NewReaderDict(br, dict)
+}
+
+// tailBuffer keeps the last bytes passing through a stream
+type tailBuffer struct {
+	size int
+	buf  []byte
+}
+
+func (t *tailBuffer) add(p []byte) {
+	if len(p) >= t.size {
+		t.buf = append(t.buf[:0], p[len(p)-t.size:]...)
+		return
+	}
+	if drop := len(t.buf) + len(p) - t.size; drop > 0 {
+		t.buf = append(t.buf[:0], t.buf[drop:]...)
+	}
+	t.buf = append(t.buf, p...)
+}
+
+// seedWriter records the tail of the written data as the seed for the next stream
+type seedWriter struct {
+	io.Writer
+	m    *Middleware
+	tail tailBuffer
+}
+
+func (w *seedWriter) Write(p []byte) (n int, err error) {
+	n, err = w.Writer.Write(p)
+	w.tail.add(p[:n])
+	return n, err
+}
+
+func (w *seedWriter) Flush() error {
+	return flush(w.Writer)
+}
+
+func (w *seedWriter) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	if len(w.tail.buf) == 0 {
+		return nil
+	}
+	if w.m.dictStore != nil {
+		if err := w.m.dictStore.Put(adler32.Checksum(w.tail.buf), w.tail.buf); err != nil {
+			return err
+		}
+	}
+	w.m.seeds.mu.Lock()
+	w.m.seeds.write = w.tail.buf
+	w.m.seeds.mu.Unlock()
+	return nil
+}
+
+// seedReader records the tail of a fully read stream as the seed for the next stream
+type seedReader struct {
+	io.Reader
+	m    *Middleware
+	tail tailBuffer
+}
+
+func (r *seedReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	r.tail.add(p[:n])
+	if err == io.EOF && len(r.tail.buf) > 0 {
+		r.m.seeds.mu.Lock()
+		r.m.seeds.read = r.tail.buf
+		r.m.seeds.mu.Unlock()
+	}
+	return n, err
+}
+
+func (r *seedReader) Close() error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// mapStore is an in-memory DictionaryStore
+type mapStore map[uint32][]byte
+
+func (s mapStore) Put(id uint32, dict []byte) error {
+	s[id] = append([]byte(nil), dict...)
+	return nil
+}
+
+func (s mapStore) Get(id uint32) ([]byte, bool) {
+	dict, ok := s[id]
+	return dict, ok
+}
+
+func segmentData(i int) []byte {
+	var buf bytes.Buffer
+	for j := 0; j < 200; j++ {
+		fmt.Fprintf(&buf, `{"segment":%d,"user":"user-%d","action":"login","status":"ok"}`+"\n", i, j%7)
+	}
+	return buf.Bytes()
+}
+
+func TestSegmentSeeding(t *testing.T) {
+	store := mapStore{}
+	writer := New(Zlib, WithSegmentSeeding(0), WithDictionaryStore(store))
+
+	var segments [][]byte
+	var seededSize, plainSize int
+	for i := 0; i < 3; i++ {
+		segment := compressWith(t, writer, segmentData(i))
+		segments = append(segments, segment)
+		seededSize += len(segment)
+		plainSize += len(compressWith(t, New(Zlib), segmentData(i)))
+	}
+	if seededSize >= plainSize {
+		t.Fatalf("Expected seeding to improve ratio: seeded %d, plain %d", seededSize, plainSize)
+	}
+
+	// Sequential reads resolve seeds from the previously read segment
+	reader := New(Zlib, WithSegmentSeeding(0))
+	for i, segment := range segments {
+		got, err := io.ReadAll(reader.Reader(bytes.NewReader(segment)))
+		if err != nil {
+			t.Fatalf("Segment %d: Failed to read: %v", i, err)
+		}
+		if !bytes.Equal(got, segmentData(i)) {
+			t.Fatalf("Segment %d: Data mismatch", i)
+		}
+	}
+
+	// With the dictionary store, a later segment is decodable on its own
+	got, err := io.ReadAll(New(Zlib, WithSegmentSeeding(0), WithDictionaryStore(store)).Reader(bytes.NewReader(segments[2])))
+	if err != nil {
+		t.Fatalf("Failed to read segment independently: %v", err)
+	}
+	if !bytes.Equal(got, segmentData(2)) {
+		t.Fatal("Independent segment data mismatch")
+	}
+
+	// Without the seed the segment cannot be decoded
+	_, err = io.ReadAll(New(Zlib, WithSegmentSeeding(0)).Reader(bytes.NewReader(segments[2])))
+	if !errors.Is(err, zlib.ErrDictionary) {
+		t.Fatalf("Expected zlib.ErrDictionary, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// Container layout:
+//
+//	block member 0 .. block member n-1   data, one gzip member per block
+//	index member 0 .. index member k-1   empty gzip members, extra subfield "SI"
+//	footer member                        empty gzip member, extra subfield "SF"
+//
+// Every part is a valid gzip member, so the container as a whole is a regular
+// multi-member gzip stream that standard tools decompress to the original data.
+// Each index entry records the compressed and uncompressed size of one block.
+
+const (
+	// DefaultBlockSize is the default amount of uncompressed data per block
+	DefaultBlockSize = 64 * 1024
+
+	// extraMemberOverhead is the size of an empty gzip member with a single extra subfield,
+	// excluding the subfield payload: header (10) + XLEN (2) + subfield header (4) +
+	// empty deflate block (2) + trailer (8)
+	extraMemberOverhead = 26
+
+	indexEntrySize   = 8
+	maxEntriesPerMsg = (1<<16 - 1 - 4) / indexEntrySize
+
+	footerPayloadSize = 24
+	footerSize        = extraMemberOverhead + footerPayloadSize
+)
+
+var (
+	indexID  = [2]byte{'S', 'I'}
+	footerID = [2]byte{'S', 'F'}
+)
+
+var (
+	// ErrInvalidFooter is returned when the data does not end with a seekable container footer
+	ErrInvalidFooter = errors.New("seekable: invalid container footer")
+	// ErrInvalidIndex is returned when the embedded block index is malformed
+	ErrInvalidIndex = errors.New("seekable: invalid block index")
+)
+
+// extraMember builds an empty gzip member carrying payload in an extra subfield
+func extraMember(id [2]byte, payload []byte) []byte {
+	b := make([]byte, 0, extraMemberOverhead+len(payload))
+	b = append(b, 0x1f, 0x8b, 8, 1<<2, 0, 0, 0, 0, 0, 255)
+	b = binary.LittleEndian.AppendUint16(b, uint16(4+len(payload)))
+	b = append(b, id[0], id[1])
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(payload)))
+	b = append(b, payload...)
+	b = append(b, 0x03, 0x00)             // empty final deflate block
+	b = append(b, 0, 0, 0, 0, 0, 0, 0, 0) // CRC32 and ISIZE of the empty payload
+	return b
+}
+
+// parseExtraMember extracts the payload of an empty gzip member written by extraMember
+// and returns it along with the total member length
+func parseExtraMember(b []byte, id [2]byte) ([]byte, int, bool) {
+	if len(b) < extraMemberOverhead || b[0] != 0x1f || b[1] != 0x8b || b[2] != 8 || b[3] != 1<<2 {
+		return nil, 0, false
+	}
+	xlen := int(binary.LittleEndian.Uint16(b[10:]))
+	if xlen < 4 || b[12] != id[0] || b[13] != id[1] || int(binary.LittleEndian.Uint16(b[14:])) != xlen-4 {
+		return nil, 0, false
+	}
+	n := extraMemberOverhead + xlen - 4
+	if len(b) < n || b[n-10] != 0x03 || b[n-9] != 0x00 {
+		return nil, 0, false
+	}
+	return b[16 : 16+xlen-4], n, true
+}
+
+// footer locates the index within the container
+type footer struct {
+	indexOffset int64
+	blocks      int64
+	checksum    uint32 // CRC32 of the index members
+}
+
+func (f footer) marshal() []byte {
+	p := make([]byte, 0, footerPayloadSize)
+	p = binary.LittleEndian.AppendUint64(p, uint64(f.indexOffset))
+	p = binary.LittleEndian.AppendUint64(p, uint64(f.blocks))
+	p = binary.LittleEndian.AppendUint32(p, f.checksum)
+	p = append(p, 'S', 'E', 'E', 'K')
+	return extraMember(footerID, p)
+}
+
+func parseFooter(b []byte) (footer, error) {
+	p, n, ok := parseExtraMember(b, footerID)
+	if !ok || n != footerSize || len(p) != footerPayloadSize || string(p[20:]) != "SEEK" {
+		return footer{}, ErrInvalidFooter
+	}
+	f := footer{
+		indexOffset: int64(binary.LittleEndian.Uint64(p)),
+		blocks:      int64(binary.LittleEndian.Uint64(p[8:])),
+		checksum:    binary.LittleEndian.Uint32(p[16:]),
+	}
+	if f.indexOffset < 0 || f.blocks < 0 {
+		return footer{}, ErrInvalidFooter
+	}
+	return f, nil
+}
+
+// block describes one compressed block
+type block struct {
+	compressedOffset   int64
+	compressedSize     int64
+	uncompressedOffset int64
+	uncompressedSize   int64
+}
+
+// parseIndex decodes the index members into blocks
+func parseIndex(b []byte, f footer) ([]block, error) {
+	if crc32.ChecksumIEEE(b) != f.checksum {
+		return nil, ErrInvalidIndex
+	}
+	if f.blocks > int64(len(b)/indexEntrySize) {
+		return nil, ErrInvalidIndex
+	}
+
+	blocks := make([]block, 0, f.blocks)
+	var coff, uoff int64
+	for len(b) > 0 {
+		p, n, ok := parseExtraMember(b, indexID)
+		if !ok || len(p)%indexEntrySize != 0 {
+			return nil, ErrInvalidIndex
+		}
+		for ; len(p) > 0; p = p[indexEntrySize:] {
+			csize := int64(binary.LittleEndian.Uint32(p))
+			usize := int64(binary.LittleEndian.Uint32(p[4:]))
+			blocks = append(blocks, block{coff, csize, uoff, usize})
+			coff += csize
+			uoff += usize
+		}
+		b = b[n:]
+	}
+
+	if int64(len(blocks)) != f.blocks || coff != f.indexOffset {
+		return nil, ErrInvalidIndex
+	}
+	return blocks, nil
+}
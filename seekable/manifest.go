@@ -0,0 +1,99 @@
+package seekable
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// ManifestVersion is the current manifest format version
+const ManifestVersion = 1
+
+// Chunk describes one compressed block of a container
+type Chunk struct {
+	Offset             int64  `json:"offset"`
+	CompressedSize     int64  `json:"compressed_size"`
+	UncompressedOffset int64  `json:"uncompressed_offset"`
+	UncompressedSize   int64  `json:"uncompressed_size"`
+	CRC32              uint32 `json:"crc32"`  // CRC-32 (IEEE) of the compressed chunk
+	SHA256             string `json:"sha256"` // hex SHA-256 of the uncompressed chunk
+}
+
+// Manifest lists the chunks of a container, so external systems can validate,
+// deduplicate or partially fetch stored objects without scanning them
+type Manifest struct {
+	Version          int     `json:"version"`
+	BlockSize        int     `json:"block_size"`
+	CompressedSize   int64   `json:"compressed_size"` // chunks only, excluding index and footer
+	UncompressedSize int64   `json:"uncompressed_size"`
+	Complete         bool    `json:"complete"` // index and footer have been written
+	Chunks           []Chunk `json:"chunks"`
+}
+
+// WithManifest makes the Writer record a manifest entry for every chunk,
+// available through Writer.Manifest
+func WithManifest() Option {
+	return func(w *Writer) {
+		w.manifest = &Manifest{Version: ManifestVersion}
+	}
+}
+
+// Manifest returns a copy of the manifest of the chunks written so far. It
+// returns false if the Writer was created without WithManifest.
+func (w *Writer) Manifest() (Manifest, bool) {
+	if w.manifest == nil {
+		return Manifest{}, false
+	}
+	m := *w.manifest
+	m.Chunks = append([]Chunk(nil), m.Chunks...)
+	return m, true
+}
+
+// addChunk records a written chunk in the manifest
+func (w *Writer) addChunk(compressed, uncompressed []byte) {
+	if w.manifest == nil {
+		return
+	}
+	sum := sha256.Sum256(uncompressed)
+	w.manifest.Chunks = append(w.manifest.Chunks, Chunk{
+		Offset:             w.manifest.CompressedSize,
+		CompressedSize:     int64(len(compressed)),
+		UncompressedOffset: w.manifest.UncompressedSize,
+		UncompressedSize:   int64(len(uncompressed)),
+		CRC32:              crc32.ChecksumIEEE(compressed),
+		SHA256:             hex.EncodeToString(sum[:]),
+	})
+	w.manifest.BlockSize = w.blockSize
+	w.manifest.CompressedSize += int64(len(compressed))
+	w.manifest.UncompressedSize += int64(len(uncompressed))
+}
+
+// WriteTo serializes the manifest as JSON
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadManifest parses a manifest serialized with Manifest.WriteTo
+func ReadManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return Manifest{}, fmt.Errorf("seekable: invalid manifest: %w", err)
+	}
+	if m.Version != ManifestVersion {
+		return Manifest{}, fmt.Errorf("seekable: unsupported manifest version %d", m.Version)
+	}
+	return m, nil
+}
+
+// Verify checks that compressed holds the data described by c
+func (c Chunk) Verify(compressed []byte) bool {
+	return int64(len(compressed)) == c.CompressedSize && crc32.ChecksumIEEE(compressed) == c.CRC32
+}
@@ -0,0 +1,58 @@
+package seekable
+
+import (
+	"errors"
+	"io"
+	"sort"
+)
+
+// MultiReaderAt presents several containers as one continuous uncompressed
+// address space, using each container's index for random access
+type MultiReaderAt struct {
+	segments []*ReaderAt
+	offsets  []int64 // uncompressed start offset of each segment
+	size     int64
+}
+
+// NewMultiReaderAt concatenates the uncompressed contents of the given containers
+func NewMultiReaderAt(segments ...*ReaderAt) *MultiReaderAt {
+	mr := &MultiReaderAt{segments: segments, offsets: make([]int64, len(segments))}
+	for i, s := range segments {
+		mr.offsets[i] = mr.size
+		mr.size += s.Size()
+	}
+	return mr
+}
+
+// Size returns the combined uncompressed size of all segments
+func (mr *MultiReaderAt) Size() int64 {
+	return mr.size
+}
+
+// ReadAt reads len(p) bytes starting at off, spanning segment boundaries as needed
+func (mr *MultiReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("seekable: negative offset")
+	}
+	for len(p) > 0 {
+		if off >= mr.size {
+			return n, io.EOF
+		}
+		i := sort.Search(len(mr.segments), func(i int) bool {
+			return mr.offsets[i]+mr.segments[i].Size() > off
+		})
+		segOff := off - mr.offsets[i]
+		chunk := p
+		if remaining := mr.segments[i].Size() - segOff; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+		c, err := mr.segments[i].ReadAt(chunk, segOff)
+		n += c
+		off += int64(c)
+		p = p[c:]
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,243 @@
+package seekable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ReaderAt provides random access to the uncompressed contents of a container
+type ReaderAt struct {
+	ra     io.ReaderAt
+	blocks []block
+	size   int64
+
+	mu     sync.Mutex
+	cached int
+	data   []byte
+}
+
+// NewReaderAt opens the container of the given compressed size stored in ra
+func NewReaderAt(ra io.ReaderAt, size int64) (*ReaderAt, error) {
+	if size < footerSize {
+		return nil, ErrInvalidFooter
+	}
+	buf := make([]byte, footerSize)
+	if _, err := ra.ReadAt(buf, size-footerSize); err != nil {
+		return nil, fmt.Errorf("seekable: failed to read footer: %w", err)
+	}
+	f, err := parseFooter(buf)
+	if err != nil {
+		return nil, err
+	}
+	if f.indexOffset > size-footerSize {
+		return nil, ErrInvalidIndex
+	}
+
+	index := make([]byte, size-footerSize-f.indexOffset)
+	if _, err := ra.ReadAt(index, f.indexOffset); err != nil {
+		return nil, fmt.Errorf("seekable: failed to read index: %w", err)
+	}
+	blocks, err := parseIndex(index, f)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ReaderAt{ra: ra, blocks: blocks, cached: -1}
+	if n := len(blocks); n > 0 {
+		r.size = blocks[n-1].uncompressedOffset + blocks[n-1].uncompressedSize
+	}
+	return r, nil
+}
+
+// Size returns the uncompressed size of the container contents
+func (r *ReaderAt) Size() int64 {
+	return r.size
+}
+
+// ReadAt reads len(p) uncompressed bytes starting at off. Only the blocks
+// overlapping the requested range are decompressed, and their compressed bytes
+// are fetched from the underlying io.ReaderAt with a single call.
+func (r *ReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("seekable: negative offset")
+	}
+	if off >= r.size {
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+
+	end := off + int64(len(p))
+	if end > r.size {
+		end = r.size
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	first, last := r.find(off), r.find(end-1)
+	fetchFrom := first
+	if r.cached == first {
+		fetchFrom++
+	}
+
+	var compressed []byte
+	if fetchFrom <= last {
+		start := r.blocks[fetchFrom].compressedOffset
+		compressed = make([]byte, r.blocks[last].compressedOffset+r.blocks[last].compressedSize-start)
+		if _, err := r.ra.ReadAt(compressed, start); err != nil {
+			return 0, fmt.Errorf("seekable: failed to read blocks %d-%d: %w", fetchFrom, last, err)
+		}
+	}
+
+	for i := first; i <= last; i++ {
+		if i != r.cached {
+			b := r.blocks[i]
+			start := b.compressedOffset - r.blocks[fetchFrom].compressedOffset
+			if err := r.decode(i, compressed[start:start+b.compressedSize]); err != nil {
+				return n, err
+			}
+		}
+		c := copy(p[n:], r.data[off-r.blocks[i].uncompressedOffset:])
+		n += c
+		off += int64(c)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// find returns the index of the block containing the uncompressed offset off
+func (r *ReaderAt) find(off int64) int {
+	return sort.Search(len(r.blocks), func(i int) bool {
+		return r.blocks[i].uncompressedOffset+r.blocks[i].uncompressedSize > off
+	})
+}
+
+// decode decompresses block i and makes it the cached block
+func (r *ReaderAt) decode(i int, compressed []byte) error {
+	b := r.blocks[i]
+	// The size comes from the index; no writer produces blocks this large
+	if b.uncompressedSize > maxBlockSize {
+		return fmt.Errorf("seekable: corrupt block %d: size %d exceeds the maximum block size", i, b.uncompressedSize)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("seekable: corrupt block %d: %w", i, err)
+	}
+	data := make([]byte, b.uncompressedSize)
+	if _, err := io.ReadFull(gz, data); err != nil {
+		return fmt.Errorf("seekable: corrupt block %d: %w", i, err)
+	}
+	if _, err := gz.Read(make([]byte, 1)); err != io.EOF {
+		return fmt.Errorf("seekable: corrupt block %d: size mismatch", i)
+	}
+
+	r.cached, r.data = i, data
+	return nil
+}
+
+// Reader provides sequential and seekable reads of a container's uncompressed contents
+type Reader struct {
+	ra  *ReaderAt
+	off int64
+}
+
+// NewReader opens the container stored in rs
+func NewReader(rs io.ReadSeeker) (*Reader, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("seekable: failed to determine size: %w", err)
+	}
+	ra, err := NewReaderAt(&seekerReaderAt{rs: rs}, size)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{ra: ra}, nil
+}
+
+// Read reads uncompressed data from the current position
+func (r *Reader) Read(p []byte) (n int, err error) {
+	if r.off >= r.ra.Size() {
+		return 0, io.EOF
+	}
+	if remaining := r.ra.Size() - r.off; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err = r.ra.ReadAt(p, r.off)
+	r.off += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the position for the next Read in the uncompressed data
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.off
+	case io.SeekEnd:
+		offset += r.ra.Size()
+	default:
+		return 0, errors.New("seekable: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("seekable: negative position")
+	}
+	r.off = offset
+	return offset, nil
+}
+
+// Size returns the uncompressed size of the container contents
+func (r *Reader) Size() int64 {
+	return r.ra.Size()
+}
+
+// seekerReaderAt adapts an io.ReadSeeker to io.ReaderAt for single-goroutine use
+type seekerReaderAt struct {
+	rs io.ReadSeeker
+}
+
+func (s *seekerReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if _, err := s.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(s.rs, p)
+}
+
+// Check validates the structure of every indexed block without decompressing
+// it: each block must start with a gzip member header and end with a trailer
+// whose recorded size matches the index
+func (r *ReaderAt) Check() error {
+	hdr := make([]byte, 4)
+	trailer := make([]byte, 4)
+	for i, b := range r.blocks {
+		if b.compressedSize < 18 {
+			return fmt.Errorf("%w: block %d is too short", ErrInvalidIndex, i)
+		}
+		if _, err := r.ra.ReadAt(hdr, b.compressedOffset); err != nil {
+			return fmt.Errorf("seekable: failed to read block %d: %w", i, err)
+		}
+		if hdr[0] != 0x1f || hdr[1] != 0x8b || hdr[2] != 8 {
+			return fmt.Errorf("seekable: corrupt block %d: %w", i, gzip.ErrHeader)
+		}
+		if _, err := r.ra.ReadAt(trailer, b.compressedOffset+b.compressedSize-4); err != nil {
+			return fmt.Errorf("seekable: failed to read block %d: %w", i, err)
+		}
+		if int64(binary.LittleEndian.Uint32(trailer)) != b.uncompressedSize&0xffffffff {
+			return fmt.Errorf("seekable: corrupt block %d: size mismatch", i)
+		}
+	}
+	return nil
+}
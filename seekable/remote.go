@@ -0,0 +1,33 @@
+package seekable
+
+import (
+	"fmt"
+	"io"
+)
+
+// RangeFunc fetches length bytes of the compressed container starting at offset,
+// for example with an HTTP range request against an object store
+type RangeFunc func(offset, length int64) ([]byte, error)
+
+// NewRangeReader opens a remote container of the given compressed size. The
+// footer, the index and the blocks overlapping each ReadAt are fetched through
+// readRange, so point reads transfer only the compressed ranges they need
+// instead of the whole object.
+func NewRangeReader(readRange RangeFunc, size int64) (*ReaderAt, error) {
+	return NewReaderAt(rangeReaderAt(readRange), size)
+}
+
+// rangeReaderAt adapts a RangeFunc to io.ReaderAt
+type rangeReaderAt RangeFunc
+
+func (f rangeReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	data, err := f(off, int64(len(p)))
+	n = copy(p, data)
+	if err != nil {
+		return n, fmt.Errorf("seekable: range request failed: %w", err)
+	}
+	if n < len(p) {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, nil
+}
@@ -0,0 +1,39 @@
+package seekable
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRangeReader(t *testing.T) {
+	data := testData(200000)
+	container := writeContainer(t, data, WithBlockSize(8192))
+
+	var requests int
+	var transferred int64
+	readRange := func(offset, length int64) ([]byte, error) {
+		requests++
+		transferred += length
+		return container[offset : offset+length], nil
+	}
+
+	ra, err := NewRangeReader(readRange, int64(len(container)))
+	if err != nil {
+		t.Fatalf("Failed to open remote container: %v", err)
+	}
+
+	requests, transferred = 0, 0
+	p := make([]byte, 20000)
+	if _, err := ra.ReadAt(p, 100000); err != nil {
+		t.Fatalf("ReadAt failed: %v", err)
+	}
+	if !bytes.Equal(p, data[100000:120000]) {
+		t.Fatal("ReadAt returned wrong data")
+	}
+	if requests != 1 {
+		t.Fatalf("Expected a single range request, got %d", requests)
+	}
+	if transferred >= int64(len(container))/2 {
+		t.Fatalf("Expected a partial transfer, fetched %d of %d bytes", transferred, len(container))
+	}
+}
@@ -0,0 +1,114 @@
+package seekable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// ErrManifestMismatch is returned when the output does not match the manifest to resume from
+var ErrManifestMismatch = errors.New("seekable: output does not match manifest")
+
+// ResumeTarget is the output of an interrupted job, typically an *os.File
+type ResumeTarget interface {
+	io.Writer
+	io.Seeker
+	Truncate(size int64) error
+}
+
+// WithCheckpoint makes the Writer record a manifest and invoke fn with it after
+// every chunk. Persisting the manifest allows an interrupted job to be resumed
+// with Resume instead of starting over.
+func WithCheckpoint(fn func(Manifest)) Option {
+	return func(w *Writer) {
+		if w.manifest == nil {
+			w.manifest = &Manifest{Version: ManifestVersion}
+		}
+		w.checkpoint = fn
+	}
+}
+
+// Resume continues an interrupted container from the last persisted manifest.
+// Everything in out after the last chunk listed in the manifest is discarded,
+// so only the incomplete tail chunk is rewritten. The caller must continue
+// writing source data from position manifest.UncompressedSize.
+func Resume(out ResumeTarget, manifest Manifest, opts ...Option) (*Writer, error) {
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("seekable: unsupported manifest version %d", manifest.Version)
+	}
+	if manifest.Complete {
+		return nil, fmt.Errorf("seekable: container is already complete")
+	}
+	// Validate the manifest before anything in out is touched
+	if err := checkChunks(manifest); err != nil {
+		return nil, err
+	}
+
+	size, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if size < manifest.CompressedSize {
+		return nil, fmt.Errorf("%w: output has %d bytes, manifest expects %d", ErrManifestMismatch, size, manifest.CompressedSize)
+	}
+	if n := len(manifest.Chunks); n > 0 {
+		if ra, ok := out.(io.ReaderAt); ok {
+			last := manifest.Chunks[n-1]
+			chunk := make([]byte, last.CompressedSize)
+			if _, err := ra.ReadAt(chunk, last.Offset); err != nil || !last.Verify(chunk) {
+				return nil, fmt.Errorf("%w: last chunk is corrupt", ErrManifestMismatch)
+			}
+		}
+	}
+
+	if err := out.Truncate(manifest.CompressedSize); err != nil {
+		return nil, fmt.Errorf("seekable: failed to truncate output: %w", err)
+	}
+	if _, err := out.Seek(manifest.CompressedSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if manifest.BlockSize > 0 {
+		opts = append([]Option{WithBlockSize(manifest.BlockSize)}, opts...)
+	}
+	w := NewWriter(out, opts...)
+	if w.manifest == nil {
+		w.manifest = &Manifest{Version: ManifestVersion}
+	}
+
+	for _, c := range manifest.Chunks {
+		w.entries = binary.LittleEndian.AppendUint32(w.entries, uint32(c.CompressedSize))
+		w.entries = binary.LittleEndian.AppendUint32(w.entries, uint32(c.UncompressedSize))
+		w.stats.add(int(c.CompressedSize), int(c.UncompressedSize))
+	}
+	w.offset = manifest.CompressedSize
+	w.blocks = int64(len(manifest.Chunks))
+	w.manifest.BlockSize = w.blockSize
+	w.manifest.Chunks = append([]Chunk(nil), manifest.Chunks...)
+	w.manifest.CompressedSize = manifest.CompressedSize
+	w.manifest.UncompressedSize = manifest.UncompressedSize
+	return w, nil
+}
+
+// checkChunks verifies that the chunks of manifest are contiguous, fit the
+// block index and add up to the recorded sizes
+func checkChunks(manifest Manifest) error {
+	var offset, uoffset int64
+	for i, c := range manifest.Chunks {
+		if c.Offset != offset || c.UncompressedOffset != uoffset {
+			return fmt.Errorf("%w: chunk %d is not contiguous", ErrManifestMismatch, i)
+		}
+		if c.CompressedSize <= 0 || c.CompressedSize > math.MaxUint32 || c.UncompressedSize < 0 || c.UncompressedSize > maxBlockSize {
+			return fmt.Errorf("%w: chunk %d has an invalid size", ErrManifestMismatch, i)
+		}
+		offset += c.CompressedSize
+		uoffset += c.UncompressedSize
+	}
+	if offset != manifest.CompressedSize || uoffset != manifest.UncompressedSize {
+		return fmt.Errorf("%w: chunks add up to %d compressed and %d uncompressed bytes, manifest records %d and %d",
+			ErrManifestMismatch, offset, uoffset, manifest.CompressedSize, manifest.UncompressedSize)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package seekable
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResume(t *testing.T) {
+	data := testData(50000)
+	path := filepath.Join(t.TempDir(), "container")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create output: %v", err)
+	}
+
+	// Write part of the data and "crash" in the middle of a chunk, remembering
+	// the last persisted checkpoint
+	var checkpoint Manifest
+	w := NewWriter(f, WithBlockSize(4096), WithCheckpoint(func(m Manifest) {
+		checkpoint = m
+	}))
+	w.Write(data[:30000])
+	f.Write([]byte("partial tail chunk garbage"))
+	f.Close()
+
+	f, err = os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("Failed to reopen output: %v", err)
+	}
+	defer f.Close()
+
+	w, err = Resume(f, checkpoint)
+	if err != nil {
+		t.Fatalf("Failed to resume: %v", err)
+	}
+	if _, err := w.Write(data[checkpoint.UncompressedSize:]); err != nil {
+		t.Fatalf("Failed to write remaining data: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close resumed writer: %v", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		t.Fatalf("Failed to open resumed container: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read resumed container: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Resumed container data mismatch")
+	}
+}
+
+func TestResumeRejectsManifestWithoutTouchingOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "container")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create output: %v", err)
+	}
+	defer f.Close()
+
+	var checkpoint Manifest
+	w := NewWriter(f, WithBlockSize(4096), WithCheckpoint(func(m Manifest) {
+		checkpoint = m
+	}))
+	w.Write(testData(30000))
+	f.Write([]byte("partial tail chunk garbage"))
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	gap := checkpoint
+	gap.Chunks = append([]Chunk(nil), checkpoint.Chunks...)
+	gap.Chunks[1].Offset++
+	short := checkpoint
+	short.CompressedSize -= 10
+	long := checkpoint
+	long.UncompressedSize += 10
+
+	for name, manifest := range map[string]Manifest{"gap": gap, "compressed size": short, "uncompressed size": long} {
+		if _, err := Resume(f, manifest); !errors.Is(err, ErrManifestMismatch) {
+			t.Fatalf("%s: Expected ErrManifestMismatch, got %v", name, err)
+		}
+		after, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		if !bytes.Equal(after, before) {
+			t.Fatalf("%s: Expected a rejected manifest to leave the output unchanged", name)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package seekable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math/rand"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func testData(size int) []byte {
+	data := make([]byte, size)
+	rng := rand.New(rand.NewSource(1))
+	for i := range data {
+		data[i] = "abcdefgh"[rng.Intn(8)]
+	}
+	return data
+}
+
+func writeContainer(t *testing.T, data []byte, opts ...Option) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, opts...)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReaderAt_RandomReads(t *testing.T) {
+	data := testData(100000)
+	container := writeContainer(t, data, WithBlockSize(4096))
+
+	ra, err := NewReaderAt(bytes.NewReader(container), int64(len(container)))
+	if err != nil {
+		t.Fatalf("Failed to open container: %v", err)
+	}
+	if ra.Size() != int64(len(data)) {
+		t.Fatalf("Expected size %d, got %d", len(data), ra.Size())
+	}
+
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		off := rng.Intn(len(data))
+		length := rng.Intn(10000)
+		if off+length > len(data) {
+			length = len(data) - off
+		}
+		p := make([]byte, length)
+		if _, err := ra.ReadAt(p, int64(off)); err != nil {
+			t.Fatalf("ReadAt(%d, %d) failed: %v", off, length, err)
+		}
+		if !bytes.Equal(p, data[off:off+length]) {
+			t.Fatalf("ReadAt(%d, %d) returned wrong data", off, length)
+		}
+	}
+
+	// Reading past the end returns io.EOF
+	p := make([]byte, 10)
+	n, err := ra.ReadAt(p, int64(len(data)-5))
+	if n != 5 || err != io.EOF {
+		t.Fatalf("Expected 5 bytes and io.EOF, got %d and %v", n, err)
+	}
+}
+
+func TestReader_Seek(t *testing.T) {
+	data := testData(50000)
+	container := writeContainer(t, data, WithBlockSize(1000), WithLevel(1))
+
+	r, err := NewReader(bytes.NewReader(container))
+	if err != nil {
+		t.Fatalf("Failed to open container: %v", err)
+	}
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Sequential read mismatch")
+	}
+
+	if _, err := r.Seek(-1234, io.SeekEnd); err != nil {
+		t.Fatalf("Failed to seek: %v", err)
+	}
+	got, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read after seek: %v", err)
+	}
+	if !bytes.Equal(got, data[len(data)-1234:]) {
+		t.Fatal("Read after seek mismatch")
+	}
+}
+
+func TestContainerIsValidGzip(t *testing.T) {
+	data := testData(30000)
+	container := writeContainer(t, data, WithBlockSize(4096))
+
+	gz, err := gzip.NewReader(bytes.NewReader(container))
+	if err != nil {
+		t.Fatalf("Failed to open as gzip: %v", err)
+	}
+	got, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Failed to read as gzip: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Gzip read mismatch")
+	}
+}
+
+func TestEmptyContainer(t *testing.T) {
+	container := writeContainer(t, nil)
+
+	r, err := NewReader(bytes.NewReader(container))
+	if err != nil {
+		t.Fatalf("Failed to open empty container: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Expected empty data, got %d bytes and %v", len(got), err)
+	}
+}
+
+func TestInvalidContainer(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(testData(1000))
+	gz.Close()
+
+	_, err := NewReaderAt(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !errors.Is(err, ErrInvalidFooter) {
+		t.Fatalf("Expected ErrInvalidFooter for plain gzip, got %v", err)
+	}
+
+	container := writeContainer(t, testData(10000), WithBlockSize(1000))
+	container[len(container)-footerSize-20] ^= 0xff
+	_, err = NewReaderAt(bytes.NewReader(container), int64(len(container)))
+	if !errors.Is(err, ErrInvalidIndex) {
+		t.Fatalf("Expected ErrInvalidIndex for corrupted index, got %v", err)
+	}
+}
+
+func TestOversizedBlock(t *testing.T) {
+	container := writeContainer(t, testData(1000))
+	f, err := parseFooter(container[len(container)-footerSize:])
+	if err != nil {
+		t.Fatalf("Failed to parse footer: %v", err)
+	}
+
+	// Claim a block larger than any writer produces in a valid index
+	index := append([]byte(nil), container[f.indexOffset:len(container)-footerSize]...)
+	binary.LittleEndian.PutUint32(index[16+4:], maxBlockSize+1)
+	f.checksum = crc32.ChecksumIEEE(index)
+	forged := append(append(container[:f.indexOffset:f.indexOffset], index...), f.marshal()...)
+
+	r, err := NewReaderAt(bytes.NewReader(forged), int64(len(forged)))
+	if err != nil {
+		t.Fatalf("Failed to open container: %v", err)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := r.ReadAt(make([]byte, 10), 0); err == nil || !strings.Contains(err.Error(), "corrupt block") {
+		t.Fatalf("Expected a corrupt block error, got %v", err)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Fatalf("Expected the block to be rejected before allocating it, allocated %d bytes", allocated)
+	}
+}
+
+func TestMultiReaderAt(t *testing.T) {
+	var segments []*ReaderAt
+	var all []byte
+	for i, size := range []int{5000, 0, 12345, 777} {
+		data := testData(size + i)
+		all = append(all, data...)
+		container := writeContainer(t, data, WithBlockSize(1024))
+		ra, err := NewReaderAt(bytes.NewReader(container), int64(len(container)))
+		if err != nil {
+			t.Fatalf("Failed to open segment %d: %v", i, err)
+		}
+		segments = append(segments, ra)
+	}
+
+	mr := NewMultiReaderAt(segments...)
+	if mr.Size() != int64(len(all)) {
+		t.Fatalf("Expected size %d, got %d", len(all), mr.Size())
+	}
+
+	p := make([]byte, 8000)
+	if _, err := mr.ReadAt(p, 3000); err != nil {
+		t.Fatalf("ReadAt across segments failed: %v", err)
+	}
+	if !bytes.Equal(p, all[3000:11000]) {
+		t.Fatal("ReadAt across segments returned wrong data")
+	}
+
+	got, err := io.ReadAll(io.NewSectionReader(mr, 0, mr.Size()))
+	if err != nil {
+		t.Fatalf("Failed to read all segments: %v", err)
+	}
+	if !bytes.Equal(got, all) {
+		t.Fatal("Full read across segments mismatch")
+	}
+}
+
+func TestWriterStats(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithBlockSize(4096))
+
+	// Four compressible blocks followed by two incompressible ones
+	w.Write(bytes.Repeat([]byte("a"), 4*4096))
+	noise := make([]byte, 2*4096)
+	rand.New(rand.NewSource(3)).Read(noise)
+	w.Write(noise)
+	w.Close()
+
+	stats := w.Stats()
+	if stats.Blocks != 6 {
+		t.Fatalf("Expected 6 blocks, got %d", stats.Blocks)
+	}
+	if stats.UncompressedBytes != 6*4096 {
+		t.Fatalf("Expected %d uncompressed bytes, got %d", 6*4096, stats.UncompressedBytes)
+	}
+	if stats.RatioHistogram[0] != 4 {
+		t.Fatalf("Expected 4 highly compressible blocks, got %d", stats.RatioHistogram[0])
+	}
+	if stats.RatioHistogram[RatioBuckets-1] != 2 {
+		t.Fatalf("Expected 2 incompressible blocks, got %d", stats.RatioHistogram[RatioBuckets-1])
+	}
+
+	var sized int64
+	for _, n := range stats.SizeHistogram {
+		sized += n
+	}
+	if sized != stats.Blocks {
+		t.Fatalf("Expected size histogram to cover %d blocks, got %d", stats.Blocks, sized)
+	}
+}
+
+func TestStoredPassthrough(t *testing.T) {
+	// Compressible text in the first 4KB, noise in the rest of the block
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(4)).Read(data)
+	copy(data, strings.Repeat("compressible text\n", 4096/18))
+
+	// A stored block is written exactly like level 0
+	uncompressed := writeContainer(t, data, WithBlockSize(len(data)), WithLevel(0))
+	stored := writeContainer(t, data, WithBlockSize(len(data)), WithLevel(9), WithStoredPassthrough())
+	if !bytes.Equal(stored, uncompressed) {
+		t.Fatal("Expected stored block")
+	}
+	if compressed := writeContainer(t, data, WithBlockSize(len(data)), WithLevel(9)); bytes.Equal(compressed, uncompressed) {
+		t.Fatal("Expected compressed block without WithStoredPassthrough")
+	}
+
+	r, err := NewReader(bytes.NewReader(stored))
+	if err != nil {
+		t.Fatalf("Failed to open container: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestManifest(t *testing.T) {
+	data := testData(10000)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithBlockSize(3000), WithManifest())
+	w.Write(data)
+	w.Close()
+
+	m, ok := w.Manifest()
+	if !ok {
+		t.Fatal("Expected manifest")
+	}
+	if !m.Complete || len(m.Chunks) != 4 || m.UncompressedSize != int64(len(data)) {
+		t.Fatalf("Unexpected manifest: %+v", m)
+	}
+
+	// Round trip through JSON
+	var serialized bytes.Buffer
+	if _, err := m.WriteTo(&serialized); err != nil {
+		t.Fatalf("Failed to serialize manifest: %v", err)
+	}
+	parsed, err := ReadManifest(&serialized)
+	if err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+
+	// Every chunk can be fetched and validated on its own
+	container := buf.Bytes()
+	for i, c := range parsed.Chunks {
+		chunk := container[c.Offset : c.Offset+c.CompressedSize]
+		if !c.Verify(chunk) {
+			t.Fatalf("Chunk %d failed verification", i)
+		}
+		gz, err := gzip.NewReader(bytes.NewReader(chunk))
+		if err != nil {
+			t.Fatalf("Chunk %d is not a gzip member: %v", i, err)
+		}
+		got, _ := io.ReadAll(gz)
+		if !bytes.Equal(got, data[c.UncompressedOffset:c.UncompressedOffset+c.UncompressedSize]) {
+			t.Fatalf("Chunk %d data mismatch", i)
+		}
+	}
+
+	if _, ok := NewWriter(io.Discard).Manifest(); ok {
+		t.Fatal("Expected no manifest without WithManifest")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	container := writeContainer(t, testData(20000), WithBlockSize(4096))
+
+	ra, err := NewReaderAt(bytes.NewReader(container), int64(len(container)))
+	if err != nil {
+		t.Fatalf("Failed to open container: %v", err)
+	}
+	if err := ra.Check(); err != nil {
+		t.Fatalf("Expected valid container, got %v", err)
+	}
+
+	// Corrupt the trailer of the first block
+	first := ra.blocks[0]
+	container[first.compressedOffset+first.compressedSize-1] ^= 0xff
+	if err := ra.Check(); err == nil {
+		t.Fatal("Expected error for corrupted block trailer")
+	}
+}
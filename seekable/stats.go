@@ -0,0 +1,53 @@
+package seekable
+
+import "math/bits"
+
+// RatioBuckets is the number of buckets in Stats.RatioHistogram
+const RatioBuckets = 11
+
+// Stats summarizes the blocks written by a Writer
+type Stats struct {
+	Blocks            int64
+	UncompressedBytes int64
+	CompressedBytes   int64
+
+	// SizeHistogram counts blocks by compressed size: bucket i holds blocks of
+	// [2^i, 2^(i+1)) bytes, bucket 0 also holds empty blocks
+	SizeHistogram [32]int64
+	// RatioHistogram counts blocks by compressed/uncompressed ratio in steps of
+	// 0.1: bucket i holds ratios in [i/10, (i+1)/10), the last bucket holds
+	// incompressible blocks with a ratio >= 1
+	RatioHistogram [RatioBuckets]int64
+}
+
+// Ratio returns the overall compressed size relative to the uncompressed size
+func (s Stats) Ratio() float64 {
+	if s.UncompressedBytes == 0 {
+		return 0
+	}
+	return float64(s.CompressedBytes) / float64(s.UncompressedBytes)
+}
+
+// add records a block in the statistics
+func (s *Stats) add(compressed, uncompressed int) {
+	s.Blocks++
+	s.CompressedBytes += int64(compressed)
+	s.UncompressedBytes += int64(uncompressed)
+
+	sizeBucket := 0
+	if compressed > 0 {
+		sizeBucket = bits.Len32(uint32(compressed)) - 1
+	}
+	s.SizeHistogram[sizeBucket]++
+
+	ratioBucket := RatioBuckets - 1
+	if compressed < uncompressed {
+		ratioBucket = compressed * 10 / uncompressed
+	}
+	s.RatioHistogram[ratioBucket]++
+}
+
+// Stats returns the block statistics of the data written so far
+func (w *Writer) Stats() Stats {
+	return w.stats
+}
@@ -0,0 +1,238 @@
+// Package seekable implements a seekable compressed container for HybridBuffer.
+//
+// Data is compressed in independent blocks followed by an embedded block index
+// and a fixed-size footer, which allows random reads without decompressing the
+// data in front of the requested range and without external index files. The
+// container is a valid multi-member gzip stream, so standard gzip tools can
+// still decompress it sequentially.
+package seekable
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// maxBlockSize keeps block sizes representable in the index
+	maxBlockSize = 1 << 30
+
+	// sampleParts and samplePartSize describe the slices of a block that are
+	// compressed to decide whether the block is stored
+	sampleParts    = 8
+	samplePartSize = 512
+
+	// storedRatio is the compressed to original size ratio of a block sample
+	// above which the block is stored
+	storedRatio = 0.98
+)
+
+// Writer compresses data into a seekable container
+type Writer struct {
+	w         io.Writer
+	level     int
+	blockSize int
+
+	buf     []byte
+	out     bytes.Buffer
+	gz      *gzip.Writer
+	stored  *gzip.Writer // writes stored blocks, with WithStoredPassthrough
+	sample  []byte
+	offset  int64
+	entries []byte
+	blocks  int64
+	stats   Stats
+	closed  bool
+
+	manifest   *Manifest
+	checkpoint func(Manifest)
+	header     *gzip.Header
+}
+
+// Option configures a Writer
+type Option func(*Writer)
+
+// WithBlockSize sets the amount of uncompressed data per block. Smaller blocks
+// make random reads cheaper at the cost of compression ratio.
+func WithBlockSize(size int) Option {
+	return func(w *Writer) {
+		if size > 0 && size <= maxBlockSize {
+			w.blockSize = size
+		}
+	}
+}
+
+// WithLevel sets the compression level (1-9, where 9 is best compression;
+// 0 stores blocks without compressing them)
+func WithLevel(level int) Option {
+	return func(w *Writer) {
+		if level >= 0 && level <= 9 {
+			w.level = level
+		}
+	}
+}
+
+// WithStoredPassthrough writes blocks that barely compress, such as
+// already-compressed media, as stored deflate blocks instead of spending CPU on
+// compressing them. The decision compresses slices taken from across the
+// whole block at the fastest level.
+func WithStoredPassthrough() Option {
+	return func(w *Writer) {
+		w.sample = make([]byte, 0, sampleParts*samplePartSize)
+	}
+}
+
+// WithGzipHeader sets the gzip header written with every block, for example
+// to fix the OS byte for reproducible output
+func WithGzipHeader(h gzip.Header) Option {
+	return func(w *Writer) {
+		w.header = &h
+	}
+}
+
+// NewWriter creates a Writer writing a seekable container to w
+func NewWriter(w io.Writer, opts ...Option) *Writer {
+	sw := &Writer{
+		w:         w,
+		level:     6,
+		blockSize: DefaultBlockSize,
+	}
+	for _, opt := range opts {
+		opt(sw)
+	}
+	sw.buf = make([]byte, 0, sw.blockSize)
+	return sw
+}
+
+// Write compresses p, emitting a block whenever the block size is reached
+func (w *Writer) Write(p []byte) (n int, err error) {
+	if w.closed {
+		return 0, fmt.Errorf("seekable: write to closed writer")
+	}
+	for len(p) > 0 {
+		c := copy(w.buf[len(w.buf):w.blockSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+		n += c
+		if len(w.buf) == w.blockSize {
+			if err := w.writeBlock(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush ends the current block early so all data written so far can be read back
+func (w *Writer) Flush() error {
+	if w.closed || len(w.buf) == 0 {
+		return nil
+	}
+	return w.writeBlock()
+}
+
+// writeBlock compresses the buffered data as an independent gzip member
+func (w *Writer) writeBlock() error {
+	w.out.Reset()
+	gz, level := &w.gz, w.level
+	if w.sample != nil && w.incompressible() {
+		gz, level = &w.stored, flate.NoCompression
+	}
+	if *gz == nil {
+		zw, err := gzip.NewWriterLevel(&w.out, level)
+		if err != nil {
+			return fmt.Errorf("seekable: failed to create gzip writer: %w", err)
+		}
+		*gz = zw
+	} else {
+		(*gz).Reset(&w.out)
+	}
+	if w.header != nil {
+		(*gz).Header = *w.header
+	}
+	if _, err := (*gz).Write(w.buf); err != nil {
+		return fmt.Errorf("seekable: failed to compress block: %w", err)
+	}
+	if err := (*gz).Close(); err != nil {
+		return fmt.Errorf("seekable: failed to compress block: %w", err)
+	}
+
+	if _, err := w.w.Write(w.out.Bytes()); err != nil {
+		return fmt.Errorf("seekable: failed to write block: %w", err)
+	}
+
+	w.entries = binary.LittleEndian.AppendUint32(w.entries, uint32(w.out.Len()))
+	w.entries = binary.LittleEndian.AppendUint32(w.entries, uint32(len(w.buf)))
+	w.offset += int64(w.out.Len())
+	w.blocks++
+	w.stats.add(w.out.Len(), len(w.buf))
+	w.addChunk(w.out.Bytes(), w.buf)
+	w.buf = w.buf[:0]
+	if w.checkpoint != nil {
+		m, _ := w.Manifest()
+		w.checkpoint(m)
+	}
+	return nil
+}
+
+// Close writes the final block, the block index and the footer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	var index []byte
+	for entries := w.entries; len(entries) > 0; {
+		n := min(len(entries), maxEntriesPerMsg*indexEntrySize)
+		index = append(index, extraMember(indexID, entries[:n])...)
+		entries = entries[n:]
+	}
+
+	f := footer{indexOffset: w.offset, blocks: w.blocks, checksum: crc32.ChecksumIEEE(index)}
+	if _, err := w.w.Write(append(index, f.marshal()...)); err != nil {
+		return fmt.Errorf("seekable: failed to write index: %w", err)
+	}
+	if w.manifest != nil {
+		w.manifest.Complete = true
+	}
+	return nil
+}
+
+// incompressible reports whether the buffered block barely shrinks at the
+// fastest level. It compresses one slice from the middle of each of
+// sampleParts equal segments, so data anywhere in the block is accounted for.
+func (w *Writer) incompressible() bool {
+	sample := w.buf
+	if len(w.buf) > cap(w.sample) {
+		segment := len(w.buf) / sampleParts
+		sample = w.sample[:0]
+		for i := 0; i < sampleParts; i++ {
+			start := i*segment + (segment-samplePartSize)/2
+			sample = append(sample, w.buf[start:start+samplePartSize]...)
+		}
+	}
+	if len(sample) == 0 {
+		return false
+	}
+	var cw countingWriter
+	fw, _ := flate.NewWriter(&cw, flate.BestSpeed)
+	fw.Write(sample)
+	fw.Close()
+	return float64(cw) >= storedRatio*float64(len(sample))
+}
+
+// countingWriter discards data, counting its length
+type countingWriter int64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
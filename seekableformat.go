@@ -0,0 +1,39 @@
+package compressionstdlib
+
+import (
+	"io"
+
+	"schneider.vip/hybridbuffer/middleware/compressionstdlib/seekable"
+)
+
+// WithSeekableFormat writes gzip data as a seekable container: independently
+// compressed blocks followed by an embedded block index (see the seekable
+// package). The container remains a regular multi-member gzip stream, so
+// Reader decodes it sequentially, while seekable.NewReaderAt provides random
+// access to large spilled buffers. Only gzip supports this format; NewE
+// rejects the option for other algorithms.
+func WithSeekableFormat() Option {
+	return func(m *Middleware) {
+		m.seekableFormat = true
+	}
+}
+
+// WithStoredPassthrough writes blocks of WithSeekableFormat or WithParallel
+// output that barely compress, such as already-compressed media, as stored
+// deflate blocks instead of compressing them. The decision compresses slices
+// taken from across each block at the fastest level. NewE rejects the option
+// without one of the two block formats.
+func WithStoredPassthrough() Option {
+	return func(m *Middleware) {
+		m.storedPassthrough = true
+	}
+}
+
+// seekableWriter returns the container writer for w
+func (m *Middleware) seekableWriter(w io.Writer) io.Writer {
+	opts := []seekable.Option{seekable.WithLevel(m.level), seekable.WithGzipHeader(m.gzipHeader())}
+	if m.storedPassthrough {
+		opts = append(opts, seekable.WithStoredPassthrough())
+	}
+	return seekable.NewWriter(w, opts...)
+}
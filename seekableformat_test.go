@@ -0,0 +1,51 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"schneider.vip/hybridbuffer/middleware/compressionstdlib/seekable"
+)
+
+func TestSeekableFormat(t *testing.T) {
+	data := make([]byte, 300*1024)
+	for i := range data {
+		data[i] = byte(i / 100)
+	}
+
+	m := New(Gzip, WithSeekableFormat())
+	compressed := compressWith(t, m, data)
+
+	ra, err := seekable.NewReaderAt(bytes.NewReader(compressed), int64(len(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to open container: %v", err)
+	}
+	if ra.Size() != int64(len(data)) {
+		t.Fatalf("Expected size %d, got %d", len(data), ra.Size())
+	}
+	p := make([]byte, 1000)
+	if _, err := ra.ReadAt(p, 200*1024); err != nil {
+		t.Fatalf("Failed to read at offset: %v", err)
+	}
+	if !bytes.Equal(p, data[200*1024:200*1024+1000]) {
+		t.Fatal("Random access data mismatch")
+	}
+
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Sequential data mismatch")
+	}
+}
+
+func TestSeekableFormat_IgnoredForZlib(t *testing.T) {
+	data := []byte("not a container")
+	compressed := compressWith(t, New(Zlib, WithSeekableFormat()), data)
+
+	if !bytes.Equal(compressed, compressWith(t, New(Zlib), data)) {
+		t.Fatal("Expected plain zlib output")
+	}
+}
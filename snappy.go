@@ -0,0 +1,21 @@
+//go:build snappy
+
+package compressionstdlib
+
+import (
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+func init() {
+	registerCodec(Snappy, codec{
+		// Snappy has no compression levels
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			return snappy.NewBufferedWriter(w), nil
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(snappy.NewReader(r)), nil
+		},
+	})
+}
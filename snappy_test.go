@@ -0,0 +1,29 @@
+//go:build snappy
+
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestSnappyRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("snappy backend "), 5000)
+	m, err := NewE(Snappy)
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+	compressed := compressWith(t, m, data)
+	// The framing format starts with the stream identifier chunk
+	if !bytes.HasPrefix(compressed, []byte("\xff\x06\x00\x00sNaPpY")) {
+		t.Fatalf("Expected the snappy stream identifier, got % x", compressed[:10])
+	}
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
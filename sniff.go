@@ -0,0 +1,40 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+)
+
+// Sniff identifies the compression of the stream read from r by its leading
+// magic bytes. It recognizes gzip, zlib, bzip2, zstd, xz and lz4; other
+// input, including raw deflate, which has no signature, is reported as None.
+// The zlib header is only two bytes, so plain data may occasionally be taken
+// for zlib. The returned reader replays the inspected bytes followed by the
+// rest of r, so it can be passed to the Reader of a middleware for the
+// detected algorithm. Errors reading r, other than reaching its end, are
+// returned.
+func Sniff(r io.Reader) (Algorithm, io.Reader, error) {
+	head := make([]byte, nestedCheckSize)
+	n, err := io.ReadFull(r, head)
+	head = head[:n]
+	replay := io.MultiReader(bytes.NewReader(head), r)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return None, replay, err
+	}
+	return sniffAlgorithm(head), replay, nil
+}
+
+// sniffAlgorithm returns the algorithm p starts with, or None
+func sniffAlgorithm(p []byte) Algorithm {
+	if algorithm, ok := detectAlgorithm(p); ok {
+		return algorithm
+	}
+	for _, f := range compressedFormats {
+		if bytes.HasPrefix(p, f.magic) {
+			if algorithm, err := ParseAlgorithm(f.name); err == nil {
+				return algorithm
+			}
+		}
+	}
+	return None
+}
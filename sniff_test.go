@@ -0,0 +1,53 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSniff(t *testing.T) {
+	data := []byte("mixed-format ingestion\n")
+	inputs := map[Algorithm][]byte{
+		Gzip:  compressWith(t, New(Gzip), data),
+		Zlib:  compressWith(t, New(Zlib), data),
+		None:  data,
+		Bzip2: []byte("BZh91AY&SY"),
+	}
+	for want, input := range inputs {
+		alg, r, err := Sniff(bytes.NewReader(input))
+		if err != nil {
+			t.Fatalf("%v: failed to sniff: %v", want, err)
+		}
+		if alg != want {
+			t.Fatalf("Expected %v, got %v", want, alg)
+		}
+		replayed, err := io.ReadAll(r)
+		if err != nil || !bytes.Equal(replayed, input) {
+			t.Fatalf("%v: expected the input to be replayed, got %q (%v)", want, replayed, err)
+		}
+		if want == Gzip || want == Zlib {
+			got, err := io.ReadAll(New(alg).Reader(bytes.NewReader(replayed)))
+			if err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("%v: failed to read: %v", want, err)
+			}
+		}
+	}
+
+	// Inputs shorter than the longest signature
+	if alg, r, err := Sniff(bytes.NewReader([]byte("ab"))); err != nil || alg != None {
+		t.Fatalf("Expected None, got %v (%v)", alg, err)
+	} else if got, _ := io.ReadAll(r); string(got) != "ab" {
+		t.Fatalf("Expected %q, got %q", "ab", got)
+	}
+	if alg, _, err := Sniff(bytes.NewReader(nil)); err != nil || alg != None {
+		t.Fatalf("Expected None for empty input, got %v (%v)", alg, err)
+	}
+
+	failure := errors.New("connection reset")
+	if _, _, err := Sniff(iotest.ErrReader(failure)); !errors.Is(err, failure) {
+		t.Fatalf("Expected the read error, got %v", err)
+	}
+}
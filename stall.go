@@ -0,0 +1,121 @@
+package compressionstdlib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrStalled is returned when the underlying stream makes no progress within
+// the stall timeout. It also matches os.ErrDeadlineExceeded.
+var ErrStalled = errors.New("stream stalled")
+
+// WithStallTimeout aborts a Read, Write or Flush with ErrStalled when a single
+// operation on the underlying stream makes no progress for d, so hung storage
+// backends do not block callers forever. The blocked operation keeps running
+// in the background until the backend returns, and the stream fails from then
+// on. Values of zero or below disable the watchdog.
+func WithStallTimeout(d time.Duration) Option {
+	return func(m *Middleware) {
+		m.stallTimeout = max(d, 0)
+	}
+}
+
+// watchWriter guards w with the stall watchdog
+func (m *Middleware) watchWriter(w io.Writer) io.Writer {
+	if m.stallTimeout <= 0 {
+		return w
+	}
+	return &stallWriter{stallGuard: stallGuard{timeout: m.stallTimeout}, w: w}
+}
+
+// watchReader guards r with the stall watchdog
+func (m *Middleware) watchReader(r io.Reader) io.Reader {
+	if m.stallTimeout <= 0 {
+		return r
+	}
+	return &stallReader{stallGuard: stallGuard{timeout: m.stallTimeout}, r: r}
+}
+
+// stallGuard runs operations in the background and gives up on them after the timeout
+type stallGuard struct {
+	timeout time.Duration
+	buf     []byte // owned by the pending operation once it stalled
+	err     error
+}
+
+type stallResult struct {
+	n   int
+	err error
+}
+
+// buffer returns a scratch buffer of size n. It is only reused after the
+// operation using it has returned.
+func (g *stallGuard) buffer(n int) []byte {
+	if cap(g.buf) < n {
+		g.buf = make([]byte, n)
+	}
+	return g.buf[:n]
+}
+
+// run executes op in the background, returning ErrStalled if it does not
+// complete in time
+func (g *stallGuard) run(op func() (int, error)) (int, error) {
+	done := make(chan stallResult, 1)
+	go func() {
+		n, err := op()
+		done <- stallResult{n, err}
+	}()
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-timer.C:
+		// The pending operation keeps the buffer
+		g.buf = nil
+		g.err = fmt.Errorf("%w: no progress for %v: %w", ErrStalled, g.timeout, os.ErrDeadlineExceeded)
+		return 0, g.err
+	}
+}
+
+// stallWriter writes a copy of each buffer, since a stalled write outlives the call
+type stallWriter struct {
+	stallGuard
+	w io.Writer
+}
+
+func (s *stallWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	buf := s.buffer(len(p))
+	copy(buf, p)
+	return s.run(func() (int, error) { return s.w.Write(buf) })
+}
+
+func (s *stallWriter) Flush() error {
+	if s.err != nil {
+		return s.err
+	}
+	_, err := s.run(func() (int, error) { return 0, flush(s.w) })
+	return err
+}
+
+// stallReader reads into its own buffer, since a stalled read outlives the call
+type stallReader struct {
+	stallGuard
+	r io.Reader
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	buf := s.buffer(len(p))
+	n, err := s.run(func() (int, error) { return s.r.Read(buf) })
+	copy(p, buf[:n])
+	return n, err
+}
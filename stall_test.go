@@ -0,0 +1,99 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// hungStream blocks every Read and Write until released
+type hungStream struct {
+	release chan struct{}
+}
+
+func (h *hungStream) Read(p []byte) (int, error) {
+	<-h.release
+	return 0, io.EOF
+}
+
+func (h *hungStream) Write(p []byte) (int, error) {
+	<-h.release
+	return len(p), nil
+}
+
+func TestStallTimeout_Writer(t *testing.T) {
+	dst := &hungStream{release: make(chan struct{})}
+	defer close(dst.release)
+
+	w := New(Gzip, WithStallTimeout(20*time.Millisecond)).Writer(dst).(io.WriteCloser)
+	w.Write(bytes.Repeat([]byte("stalled "), 1000))
+	err := w.Close()
+	if !errors.Is(err, ErrStalled) || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Expected ErrStalled, got %v", err)
+	}
+}
+
+// hungFlusher accepts writes but blocks every Flush until released
+type hungFlusher struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (h *hungFlusher) Flush() error {
+	<-h.release
+	return nil
+}
+
+func TestStallTimeout_Flush(t *testing.T) {
+	dst := &hungFlusher{release: make(chan struct{})}
+	defer close(dst.release)
+
+	w := New(Gzip, WithStallTimeout(20*time.Millisecond)).watchWriter(dst)
+	if _, err := w.Write([]byte("flushed")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := flush(w); !errors.Is(err, ErrStalled) {
+		t.Fatalf("Expected ErrStalled, got %v", err)
+	}
+	if _, err := w.Write([]byte("more")); !errors.Is(err, ErrStalled) {
+		t.Fatalf("Expected stream to stay failed, got %v", err)
+	}
+}
+
+func TestStallTimeout_Reader(t *testing.T) {
+	src := &hungStream{release: make(chan struct{})}
+	defer close(src.release)
+
+	r := New(Gzip, WithStallTimeout(20*time.Millisecond)).Reader(src)
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrStalled) {
+		t.Fatalf("Expected ErrStalled, got %v", err)
+	}
+	if _, err := r.Read(make([]byte, 1)); !errors.Is(err, ErrStalled) {
+		t.Fatalf("Expected stream to stay failed, got %v", err)
+	}
+}
+
+func TestStallTimeout_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("steady progress "), 10000)
+	m := New(Zlib, WithStallTimeout(time.Second))
+
+	var buf bytes.Buffer
+	w := m.Writer(&buf).(io.WriteCloser)
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	got, err := io.ReadAll(m.Reader(&buf))
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
@@ -0,0 +1,144 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stream header layout:
+//
+//	magic (4) | version (1) | algorithm (1) | level (1) | flags (1)
+//
+// With streamFlagDictionary set, the name of the preset dictionary follows:
+//
+//	name length (1) | name
+//
+// The low four flag bits mark features a reader must understand to decode the
+// stream; the high four bits mark optional features that older readers ignore.
+const (
+	streamHeaderSize    = 8
+	streamHeaderVersion = 1
+
+	streamFlagDictionary = 1 << 0
+
+	streamFlagsRequired = 0x0f
+	streamFlagsKnown    = streamFlagDictionary
+)
+
+var streamMagic = []byte{0x89, 'H', 'B', 'C'}
+
+// ErrInvalidStreamHeader is returned when a stream does not start with a valid stream header
+var ErrInvalidStreamHeader = errors.New("invalid stream header")
+
+// ErrUnsupportedFormat is matched by UnsupportedFormatError
+var ErrUnsupportedFormat = errors.New("unsupported stream format")
+
+// UnsupportedFormatError is returned when a stream header was written by a
+// newer format version or requires features this reader does not know. The
+// stream is intact, but needs a newer version of this package to be read.
+// It matches both ErrUnsupportedFormat and ErrInvalidStreamHeader.
+type UnsupportedFormatError struct {
+	// Version is the format version recorded in the header
+	Version int
+	// Flags holds the unknown required feature flags
+	Flags byte
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	if e.Version > streamHeaderVersion {
+		return fmt.Sprintf("unsupported stream format: version %d is newer than %d", e.Version, streamHeaderVersion)
+	}
+	return fmt.Sprintf("unsupported stream format: unknown required features %#x", e.Flags)
+}
+
+func (e *UnsupportedFormatError) Unwrap() []error {
+	return []error{ErrUnsupportedFormat, ErrInvalidStreamHeader}
+}
+
+// streamHeader describes how the stream following it was compressed
+type streamHeader struct {
+	algorithm Algorithm
+	level     int
+	flags     byte
+
+	// dictionary is the registered name of the preset dictionary, if any
+	dictionary string
+}
+
+func marshalStreamHeader(h streamHeader) []byte {
+	b := make([]byte, 0, streamHeaderSize+1+len(h.dictionary))
+	b = append(b, streamMagic...)
+	flags := h.flags
+	if h.dictionary != "" {
+		flags |= streamFlagDictionary
+	}
+	b = append(b, streamHeaderVersion, byte(h.algorithm), byte(int8(h.level)), flags)
+	if h.dictionary != "" {
+		b = append(b, byte(len(h.dictionary)))
+		b = append(b, h.dictionary...)
+	}
+	return b
+}
+
+// readStreamHeader consumes and validates the stream header at the start of r
+func readStreamHeader(r io.Reader) (streamHeader, error) {
+	b := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return streamHeader{}, fmt.Errorf("%w: %w", ErrInvalidStreamHeader, noEOF(err))
+	}
+	if !bytes.Equal(b[:4], streamMagic) {
+		return streamHeader{}, fmt.Errorf("%w: bad magic", ErrInvalidStreamHeader)
+	}
+	switch {
+	case b[4] == 0:
+		return streamHeader{}, fmt.Errorf("%w: version 0", ErrInvalidStreamHeader)
+	case b[4] > streamHeaderVersion:
+		return streamHeader{}, &UnsupportedFormatError{Version: int(b[4])}
+	case b[7]&streamFlagsRequired&^streamFlagsKnown != 0:
+		return streamHeader{}, &UnsupportedFormatError{Version: int(b[4]), Flags: b[7] & streamFlagsRequired &^ streamFlagsKnown}
+	}
+	// Optional features of newer writers are ignored
+	h := streamHeader{algorithm: Algorithm(b[5]), level: int(int8(b[6])), flags: b[7] & streamFlagsKnown}
+	if _, ok := algorithmNames[h.algorithm]; !ok || h.algorithm == Auto {
+		return streamHeader{}, fmt.Errorf("%w: unknown algorithm %d", ErrInvalidStreamHeader, b[5])
+	}
+
+	if h.flags&streamFlagDictionary != 0 {
+		if _, err := io.ReadFull(r, b[:1]); err != nil {
+			return streamHeader{}, fmt.Errorf("%w: %w", ErrInvalidStreamHeader, noEOF(err))
+		}
+		name := make([]byte, b[0])
+		if _, err := io.ReadFull(r, name); err != nil {
+			return streamHeader{}, fmt.Errorf("%w: %w", ErrInvalidStreamHeader, noEOF(err))
+		}
+		h.dictionary = string(name)
+	}
+	return h, nil
+}
+
+// WithStreamHeader writes a small self-describing header (magic bytes, format
+// version, feature flags, algorithm and level) before each compressed stream.
+// Readers with this option decode the stream with the algorithm recorded in the
+// header, so data stays readable after the configured algorithm changes; streams
+// without a header are decoded with the configured algorithm. Streams written
+// by a newer, incompatible format version fail with an UnsupportedFormatError.
+func WithStreamHeader() Option {
+	return func(m *Middleware) {
+		m.streamHeader = true
+	}
+}
+
+// fromHeader returns the middleware decoding the stream described by h
+func (m *Middleware) fromHeader(h streamHeader) (*Middleware, error) {
+	d := m.derive(h.algorithm, h.level)
+	if h.dictionary != "" {
+		dict, ok := lookupDictionary(h.dictionary)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownDictionary, h.dictionary)
+		}
+		d.dictionary = dict
+	}
+	return d, nil
+}
@@ -0,0 +1,83 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestStreamHeader_AlgorithmChanged(t *testing.T) {
+	data := bytes.Repeat([]byte("self-describing stream\n"), 500)
+
+	for _, alg := range []Algorithm{Gzip, Zlib, Flate, LZW, RLE, None} {
+		compressed := compressWith(t, New(alg, WithStreamHeader()), data)
+		if !bytes.HasPrefix(compressed, streamMagic) {
+			t.Fatalf("%v: Missing stream header", alg)
+		}
+
+		// Read back with a different configured algorithm
+		got, err := io.ReadAll(New(Zlib, WithStreamHeader()).Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%v: Failed to read: %v", alg, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: Data mismatch", alg)
+		}
+	}
+}
+
+func TestStreamHeader_Legacy(t *testing.T) {
+	data := bytes.Repeat([]byte("written without header\n"), 100)
+	compressed := compressWith(t, New(Gzip), data)
+
+	got, err := io.ReadAll(New(Gzip, WithStreamHeader()).Reader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+}
+
+func TestStreamHeader_UnknownVersion(t *testing.T) {
+	compressed := compressWith(t, New(Gzip, WithStreamHeader()), []byte("data"))
+	compressed[4] = streamHeaderVersion + 1
+
+	_, err := io.ReadAll(New(Gzip, WithStreamHeader()).Reader(bytes.NewReader(compressed)))
+	if !errors.Is(err, ErrInvalidStreamHeader) {
+		t.Fatalf("Expected ErrInvalidStreamHeader, got %v", err)
+	}
+	var unsupported *UnsupportedFormatError
+	if !errors.As(err, &unsupported) || unsupported.Version != streamHeaderVersion+1 {
+		t.Fatalf("Expected UnsupportedFormatError for version %d, got %v", streamHeaderVersion+1, err)
+	}
+	if errors.Is(err, ErrCorruptedStream) {
+		t.Fatalf("Newer format reported as corrupted: %v", err)
+	}
+}
+
+func TestStreamHeader_FeatureFlags(t *testing.T) {
+	data := []byte("feature flags")
+	compressed := compressWith(t, New(Gzip, WithStreamHeader()), data)
+
+	// Unknown optional features are ignored
+	optional := bytes.Clone(compressed)
+	optional[7] |= 0x80
+	got, err := io.ReadAll(New(Gzip, WithStreamHeader()).Reader(bytes.NewReader(optional)))
+	if err != nil {
+		t.Fatalf("Failed to read with optional flag: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Data mismatch")
+	}
+
+	// Unknown required features are rejected
+	required := bytes.Clone(compressed)
+	required[7] |= 0x04
+	_, err = io.ReadAll(New(Gzip, WithStreamHeader()).Reader(bytes.NewReader(required)))
+	var unsupported *UnsupportedFormatError
+	if !errors.As(err, &unsupported) || unsupported.Flags != 0x04 || !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Expected UnsupportedFormatError for flag 0x04, got %v", err)
+	}
+}
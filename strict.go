@@ -0,0 +1,61 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTrailingData is returned in strict mode when data follows the end of the compressed stream
+var ErrTrailingData = errors.New("trailing data after compressed stream")
+
+// WithStrictTrailer makes Reader fail with ErrTrailingData if the underlying
+// reader has bytes left once the compressed stream has ended, catching
+// corruption and accidental concatenation early. Back-to-back streams are
+// still read as one while multistream reading is enabled; combine with
+// WithMultistream(false) to reject them as well. RLE and None streams have no
+// end marker, so trailing data cannot be told apart from the stream.
+func WithStrictTrailer() Option {
+	return func(m *Middleware) {
+		m.strictTrailer = true
+	}
+}
+
+// strictSource buffers r so decompressors reading through it never consume
+// bytes beyond the end of their stream. Decompressors that buffer on their own
+// reuse the returned reader as long as it is at least as large as they need.
+func (m *Middleware) strictSource(r io.Reader) *bufio.Reader {
+	size := 4096
+	if m.headerLimits != nil {
+		size = max(size, m.headerLimits.bufferSize())
+	} else if m.uncheckedFor(m.algorithm) {
+		size = max(size, headerLimits{}.bufferSize())
+	}
+	return bufio.NewReaderSize(r, size)
+}
+
+// trailingReader reports data left in src once the decompressed stream ends
+type trailingReader struct {
+	io.Reader
+	src *bufio.Reader
+}
+
+func (r *trailingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		if b, perr := r.src.Peek(1); len(b) > 0 {
+			return n, fmt.Errorf("%w: %d or more bytes", ErrTrailingData, r.src.Buffered())
+		} else if perr != io.EOF {
+			return n, perr
+		}
+	}
+	return n, err
+}
+
+func (r *trailingReader) Close() error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestStrictTrailer(t *testing.T) {
+	data := bytes.Repeat([]byte("strict trailer "), 300)
+
+	for _, m := range []*Middleware{
+		New(Gzip, WithStrictTrailer()),
+		New(Gzip, WithStrictTrailer(), WithHeaderLimits(16, 16, 16)),
+		New(Gzip, WithStrictTrailer(), WithTrustedPipeline()),
+		New(Zlib, WithStrictTrailer()),
+		New(Flate, WithStrictTrailer()),
+		New(LZW, WithStrictTrailer()),
+	} {
+		compressed := compressWith(t, m, data)
+
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%v: Failed to read clean stream: %v", m.algorithm, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: Data mismatch", m.algorithm)
+		}
+
+		garbage := append(compressed, "garbage"...)
+		_, err = io.ReadAll(m.Reader(bytes.NewReader(garbage)))
+		if !errors.Is(err, ErrCorruptedStream) {
+			t.Fatalf("%v: Expected error for trailing garbage, got %v", m.algorithm, err)
+		}
+	}
+}
+
+func TestStrictTrailer_Lenient(t *testing.T) {
+	compressed := compressWith(t, New(Zlib), []byte("lenient"))
+	garbage := append(compressed, "garbage"...)
+
+	if _, err := io.ReadAll(New(Zlib).Reader(bytes.NewReader(garbage))); err != nil {
+		t.Fatalf("Expected trailing data to be ignored without strict mode, got %v", err)
+	}
+}
+
+func TestStrictTrailer_Concatenated(t *testing.T) {
+	for _, alg := range []Algorithm{Gzip, Zlib} {
+		compressed := compressWith(t, New(alg), []byte("first"))
+		concatenated := append(compressed, compressed...)
+
+		got, err := io.ReadAll(New(alg, WithStrictTrailer()).Reader(bytes.NewReader(concatenated)))
+		if err != nil || string(got) != "firstfirst" {
+			t.Fatalf("%v: Expected concatenated streams to be read, got %q, %v", alg, got, err)
+		}
+
+		m := New(alg, WithStrictTrailer(), WithMultistream(false))
+		_, err = io.ReadAll(m.Reader(bytes.NewReader(concatenated)))
+		if !errors.Is(err, ErrTrailingData) {
+			t.Fatalf("%v: Expected ErrTrailingData, got %v", alg, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package compressionstdlib
+
+import "sort"
+
+const (
+	// maxDictionarySize is the deflate window; dictionary bytes beyond it are never referenced
+	maxDictionarySize = 32 * 1024
+	// trainGramSize is the length of the substrings counted while training
+	trainGramSize = 8
+)
+
+// TrainDictionary builds a preset dictionary for WithDictionary from sample
+// payloads. Substrings that recur across many samples are collected, ranked by
+// how many samples share them, and concatenated with the most valuable content
+// at the end, where deflate references it most cheaply. The result is at most
+// maxSize bytes, capped at the 32KB deflate window. A few thousand
+// representative samples are usually enough.
+func TrainDictionary(samples [][]byte, maxSize int) []byte {
+	if maxSize <= 0 || maxSize > maxDictionarySize {
+		maxSize = maxDictionarySize
+	}
+
+	// Count in how many samples each substring occurs
+	freq := make(map[string]int)
+	seen := make(map[string]struct{})
+	for _, sample := range samples {
+		clear(seen)
+		for i := 0; i+trainGramSize <= len(sample); i++ {
+			gram := string(sample[i : i+trainGramSize])
+			if _, ok := seen[gram]; !ok {
+				seen[gram] = struct{}{}
+				freq[gram]++
+			}
+		}
+	}
+	threshold := max(2, len(samples)/100)
+
+	// Merge runs of frequent substrings into segments, scored by their total frequency
+	scores := make(map[string]int)
+	for _, sample := range samples {
+		start, score := -1, 0
+		for i := 0; i+trainGramSize <= len(sample)+1; i++ {
+			f := 0
+			if i+trainGramSize <= len(sample) {
+				f = freq[string(sample[i:i+trainGramSize])]
+			}
+			switch {
+			case f >= threshold && start < 0:
+				start, score = i, f
+			case f >= threshold:
+				score += f
+			case start >= 0:
+				segment := string(sample[start : i-1+trainGramSize])
+				scores[segment] = max(scores[segment], score)
+				start = -1
+			}
+		}
+	}
+
+	segments := make([]string, 0, len(scores))
+	for segment := range scores {
+		segments = append(segments, segment)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		if scores[segments[i]] != scores[segments[j]] {
+			return scores[segments[i]] > scores[segments[j]]
+		}
+		return segments[i] < segments[j]
+	})
+
+	// Pick the best segments first, skipping those mostly covered by earlier
+	// picks, then place them so the best one ends the dictionary
+	var picked []string
+	covered := make(map[string]struct{})
+	size := 0
+	for _, segment := range segments {
+		if size+len(segment) > maxSize {
+			continue
+		}
+		grams, fresh := 0, 0
+		for i := 0; i+trainGramSize <= len(segment); i++ {
+			grams++
+			if _, ok := covered[segment[i:i+trainGramSize]]; !ok {
+				fresh++
+			}
+		}
+		if fresh*2 < grams {
+			continue
+		}
+		for i := 0; i+trainGramSize <= len(segment); i++ {
+			covered[segment[i:i+trainGramSize]] = struct{}{}
+		}
+		picked = append(picked, segment)
+		size += len(segment)
+	}
+
+	dict := make([]byte, 0, size)
+	for i := len(picked) - 1; i >= 0; i-- {
+		dict = append(dict, picked[i]...)
+	}
+	return dict
+}
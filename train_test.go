@@ -0,0 +1,52 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestTrainDictionary(t *testing.T) {
+	sample := func(i int) []byte {
+		return []byte(fmt.Sprintf(`{"event":"page_view","id":%d,"user_agent":"Mozilla/5.0 (X11; Linux x86_64)","path":"/docs/%d","status":200,"tags":["spill","buffer"],"region":"eu-central-1"}`, i, i%17))
+	}
+	var samples [][]byte
+	for i := 0; i < 500; i++ {
+		samples = append(samples, sample(i))
+	}
+
+	dict := TrainDictionary(samples, 1024)
+	if len(dict) == 0 || len(dict) > 1024 {
+		t.Fatalf("Expected dictionary of 1-1024 bytes, got %d", len(dict))
+	}
+	if !bytes.Contains(dict, []byte(`"user_agent":"Mozilla/5.0 (X11; Linux x86_64)"`)) {
+		t.Fatalf("Expected shared content in dictionary, got %q", dict)
+	}
+
+	// An unseen payload compresses better with the trained dictionary
+	data := sample(100000)
+	m := New(Flate, WithDictionary(dict))
+	trained := compressWith(t, m, data)
+	if plain := compressWith(t, New(Flate), data); len(trained)*2 > len(plain) {
+		t.Fatalf("Expected trained dictionary to at least halve the size, got %d vs %d", len(trained), len(plain))
+	}
+	got, err := io.ReadAll(m.Reader(bytes.NewReader(trained)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Round trip failed: %v", err)
+	}
+}
+
+func TestTrainDictionaryLimits(t *testing.T) {
+	if dict := TrainDictionary(nil, 100); len(dict) != 0 {
+		t.Fatalf("Expected empty dictionary without samples, got %d bytes", len(dict))
+	}
+
+	samples := make([][]byte, 100)
+	for i := range samples {
+		samples[i] = bytes.Repeat([]byte(fmt.Sprintf("segment %d shared by all samples; ", i%10)), 50)
+	}
+	if dict := TrainDictionary(samples, 0); len(dict) > maxDictionarySize {
+		t.Fatalf("Expected dictionary capped at %d bytes, got %d", maxDictionarySize, len(dict))
+	}
+}
@@ -0,0 +1,102 @@
+package compressionstdlib
+
+import (
+	"fmt"
+	"io"
+
+	"schneider.vip/hybridbuffer/middleware"
+)
+
+// Stats reports the amount of data processed by a stream operation
+type Stats struct {
+	// CompressedBytes is the number of compressed bytes read from the source
+	CompressedBytes int64
+	// UncompressedBytes is the number of decompressed bytes
+	UncompressedBytes int64
+	// OutputBytes is the number of compressed bytes written, if the operation writes any
+	OutputBytes int64
+}
+
+// Ratio returns the compressed size of the source relative to its uncompressed size
+func (s Stats) Ratio() float64 {
+	if s.UncompressedBytes == 0 {
+		return 0
+	}
+	return float64(s.CompressedBytes) / float64(s.UncompressedBytes)
+}
+
+// Transcode decompresses r with src and recompresses the data into w with dst.
+// Decompression and compression run concurrently in a streaming pipeline, so
+// memory use stays bounded regardless of the stream size.
+func Transcode(dst, src middleware.Middleware, w io.Writer, r io.Reader) (Stats, error) {
+	var stats Stats
+	in := &countingReader{r: r}
+	out := &countingWriter{w: w}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		dr := src.Reader(in)
+		n, err := io.Copy(pw, dr)
+		stats.UncompressedBytes = n
+		if closer, ok := dr.(io.Closer); ok {
+			if cerr := closer.Close(); err == nil {
+				err = cerr
+			}
+		}
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	cw := dst.Writer(out)
+	source := &pipeSource{r: pr}
+	_, err := io.Copy(cw, source)
+	if err != nil && err == source.err {
+		// Decompression failed; that error is reported by the goroutine
+		err = nil
+	}
+	if closer, ok := cw.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	pr.CloseWithError(err)
+	derr := <-done
+
+	stats.CompressedBytes = in.n
+	stats.OutputBytes = out.n
+	// A compression error also fails the decompressor's pipe writes, so it
+	// must be checked first to be reported as what it is
+	if err != nil {
+		return stats, fmt.Errorf("failed to compress destination: %w", err)
+	}
+	if derr != nil && derr != io.ErrClosedPipe {
+		return stats, fmt.Errorf("failed to decompress source: %w", derr)
+	}
+	return stats, nil
+}
+
+// pipeSource remembers the last read error, telling errors of the
+// decompressed data apart from errors of the compressor
+type pipeSource struct {
+	r   io.Reader
+	err error
+}
+
+func (s *pipeSource) Read(p []byte) (n int, err error) {
+	n, err = s.r.Read(p)
+	s.err = err
+	return n, err
+}
+
+// countingReader counts the bytes read from the underlying reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
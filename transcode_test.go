@@ -0,0 +1,57 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTranscode(t *testing.T) {
+	data := bytes.Repeat([]byte("migrate me from gzip to zlib. "), 10000)
+	src := New(Gzip, WithLevel(1))
+	dst := New(Zlib, WithLevel(9))
+	compressed := compressWith(t, src, data)
+
+	var out bytes.Buffer
+	stats, err := Transcode(dst, src, &out, bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("Failed to transcode: %v", err)
+	}
+	if stats.CompressedBytes != int64(len(compressed)) {
+		t.Fatalf("Expected %d compressed bytes, got %d", len(compressed), stats.CompressedBytes)
+	}
+	if stats.UncompressedBytes != int64(len(data)) {
+		t.Fatalf("Expected %d uncompressed bytes, got %d", len(data), stats.UncompressedBytes)
+	}
+	if stats.OutputBytes != int64(out.Len()) {
+		t.Fatalf("Expected %d output bytes, got %d", out.Len(), stats.OutputBytes)
+	}
+
+	got, err := io.ReadAll(dst.Reader(&out))
+	if err != nil {
+		t.Fatalf("Failed to read transcoded data: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("Transcoded data mismatch")
+	}
+}
+
+func TestTranscode_CorruptSource(t *testing.T) {
+	compressed := compressWith(t, New(Gzip), bytes.Repeat([]byte("x"), 1000))
+	compressed[len(compressed)-6] ^= 0xff
+
+	_, err := Transcode(New(Zlib), New(Gzip), io.Discard, bytes.NewReader(compressed))
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to decompress source: ") {
+		t.Fatalf("Expected a decompression error for corrupt source, got %v", err)
+	}
+}
+
+func TestTranscode_DestinationError(t *testing.T) {
+	compressed := compressWith(t, New(Gzip), bytes.Repeat([]byte("transcode me "), 100000))
+
+	_, err := Transcode(New(Zlib), New(Gzip), failingWriter{}, bytes.NewReader(compressed))
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to compress destination: ") {
+		t.Fatalf("Expected a compression error, got %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// WithTrustedPipeline skips checksum work for internal hops where integrity is
+// guaranteed elsewhere. Streams are framed directly on top of compress/flate:
+// the writer does not compute the gzip CRC-32 or zlib Adler-32 (the trailer
+// fields are written as zero) and the reader does not verify them. Output
+// written in this mode is only readable by readers that also use it.
+func WithTrustedPipeline() Option {
+	return func(m *Middleware) {
+		m.trusted = true
+	}
+}
+
+// WithSkipChecksum makes Reader skip gzip CRC-32 and zlib Adler-32
+// verification, for read paths where the data is already integrity-protected
+// (for example encrypted and authenticated by another middleware). Unlike
+// WithTrustedPipeline it leaves the writer unchanged, so streams stay standard
+// and readable everywhere. Zlib streams with a preset dictionary or seeds are
+// still verified.
+func WithSkipChecksum() Option {
+	return func(m *Middleware) {
+		m.skipChecksum = true
+	}
+}
+
+// trustedFor reports whether the trusted pipeline handles the algorithm. Zlib
+// streams with a preset dictionary are left to the standard library.
+func (m *Middleware) trustedFor(algorithm Algorithm) bool {
+	return m.trusted && algorithm.checksummed() && (algorithm != Zlib || m.dictionary == nil)
+}
+
+// uncheckedFor reports whether streams of the algorithm are read by the
+// trusted pipeline reader, skipping checksum verification
+func (m *Middleware) uncheckedFor(algorithm Algorithm) bool {
+	if m.skipChecksum && algorithm.checksummed() && (algorithm != Zlib || (m.dictionary == nil && m.seeds == nil)) {
+		return true
+	}
+	return m.trustedFor(algorithm)
+}
+
+// trustedWriter frames a raw deflate stream as gzip or zlib without checksums
+type trustedWriter struct {
+	w         io.Writer
+	fw        *flate.Writer
+	algorithm Algorithm
+	size      uint32
+}
+
+func newTrustedWriter(w io.Writer, algorithm Algorithm, level int, os byte) (*trustedWriter, error) {
+	var header []byte
+	switch algorithm {
+	case Gzip:
+		header = []byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, os}
+		switch level {
+		case flate.BestCompression:
+			header[8] = 2
+		case flate.BestSpeed:
+			header[8] = 4
+		}
+	case Zlib:
+		header = zlibHeader(level)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	fw, err := flate.NewWriter(w, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &trustedWriter{w: w, fw: fw, algorithm: algorithm}, nil
+}
+
+// zlibHeader returns the two byte zlib header (RFC 1950) for the given level
+func zlibHeader(level int) []byte {
+	var flevel byte
+	switch {
+	case level == flate.DefaultCompression || level == 6:
+		flevel = 2
+	case level >= 7:
+		flevel = 3
+	case level >= 2:
+		flevel = 1
+	}
+	cmf, flg := byte(0x78), flevel<<6
+	flg += byte(31 - (uint16(cmf)<<8|uint16(flg))%31)
+	return []byte{cmf, flg}
+}
+
+func (w *trustedWriter) Write(p []byte) (n int, err error) {
+	n, err = w.fw.Write(p)
+	w.size += uint32(n)
+	return n, err
+}
+
+func (w *trustedWriter) Flush() error {
+	return w.fw.Flush()
+}
+
+func (w *trustedWriter) Close() error {
+	if err := w.fw.Close(); err != nil {
+		return fmt.Errorf("failed to close compressor: %w", err)
+	}
+	// Checksums are left zero; gzip additionally records the input size
+	trailer := make([]byte, 4, 8)
+	if w.algorithm == Gzip {
+		trailer = binary.LittleEndian.AppendUint32(trailer, w.size)
+	}
+	if _, err := w.w.Write(trailer); err != nil {
+		return fmt.Errorf("failed to write trailer: %w", err)
+	}
+	return nil
+}
+
+// trustedReader decodes gzip or zlib framed deflate streams without verifying checksums
+type trustedReader struct {
+	br        *bufio.Reader
+	fr        io.ReadCloser
+	algorithm Algorithm
+	limits    headerLimits
+	single    bool // stop after the first gzip member or zlib stream
+	err       error
+}
+
+func newTrustedReader(r io.Reader, algorithm Algorithm, limits *headerLimits) (*trustedReader, error) {
+	tr := &trustedReader{algorithm: algorithm}
+	if limits != nil {
+		tr.limits = *limits
+	}
+	tr.br = bufio.NewReaderSize(r, tr.limits.bufferSize())
+	if err := tr.readHeader(); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// readHeader consumes the stream header and starts a new deflate stream
+func (r *trustedReader) readHeader() error {
+	switch r.algorithm {
+	case Gzip:
+		n, err := parseGzipHeader(r.br, r.limits)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			if gzipHeaderTruncated(r.br) {
+				return io.ErrUnexpectedEOF
+			}
+			return gzip.ErrHeader
+		}
+		hdr, _ := r.br.Peek(n)
+		if err := checkHeaderCRC(hdr); err != nil {
+			return err
+		}
+		if _, err := r.br.Discard(n); err != nil {
+			return err
+		}
+	case Zlib:
+		h := make([]byte, 2)
+		if _, err := io.ReadFull(r.br, h); err != nil {
+			return noEOF(err)
+		}
+		if h[0]&0x0f != 8 || h[0]>>4 > 7 || (uint16(h[0])<<8|uint16(h[1]))%31 != 0 {
+			return zlib.ErrHeader
+		}
+		if h[1]&0x20 != 0 {
+			return zlib.ErrDictionary
+		}
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+
+	if r.fr == nil {
+		r.fr = flate.NewReader(r.br)
+	} else if err := r.fr.(flate.Resetter).Reset(r.br, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *trustedReader) Read(p []byte) (n int, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err = r.fr.Read(p)
+	if err == io.EOF {
+		err = r.nextStream()
+	}
+	r.err = err
+	return n, err
+}
+
+// nextStream skips the trailer and continues with the next gzip member or
+// zlib stream, if any
+func (r *trustedReader) nextStream() error {
+	trailer := 4
+	if r.algorithm == Gzip {
+		trailer = 8
+	}
+	if _, err := r.br.Discard(trailer); err != nil {
+		return noEOF(err)
+	}
+	if r.single {
+		return io.EOF
+	}
+	switch r.algorithm {
+	case Gzip:
+		if _, err := r.br.Peek(1); err != nil {
+			return io.EOF
+		}
+	case Zlib:
+		// As with zlibMultiReader, data without a zlib header ends the stream
+		hdr, _ := r.br.Peek(2)
+		if len(hdr) < 2 || hdr[0]&0x0f != 8 || (uint16(hdr[0])<<8|uint16(hdr[1]))%31 != 0 {
+			return io.EOF
+		}
+	}
+	return r.readHeader()
+}
+
+func (r *trustedReader) Close() error {
+	return r.fr.Close()
+}
+
+// noEOF converts io.EOF into io.ErrUnexpectedEOF for reads that must complete
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
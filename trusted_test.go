@@ -0,0 +1,93 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestTrustedPipelineRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("trusted pipeline data "), 2048)
+
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		for _, level := range []int{1, 6, 9} {
+			m := New(algorithm, WithLevel(level), WithTrustedPipeline())
+			compressed := compressWith(t, m, data)
+
+			got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Fatalf("Algorithm %d, level %d: Failed to read: %v", algorithm, level, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("Algorithm %d, level %d: Data mismatch", algorithm, level)
+			}
+		}
+	}
+}
+
+func TestTrustedPipelineSkipsVerification(t *testing.T) {
+	data := []byte("checksums are verified elsewhere")
+
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		// Standard streams with a corrupted checksum are accepted in trusted mode
+		compressed := compressWith(t, New(algorithm), data)
+		checksum := len(compressed) - 4
+		if algorithm == Gzip {
+			checksum = len(compressed) - 8
+		}
+		compressed[checksum] ^= 0xff
+
+		if _, err := io.ReadAll(New(algorithm).Reader(bytes.NewReader(compressed))); err == nil {
+			t.Fatalf("Algorithm %d: Expected checksum error from standard reader", algorithm)
+		}
+
+		got, err := io.ReadAll(New(algorithm, WithTrustedPipeline()).Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("Algorithm %d: Failed to read in trusted mode: %v", algorithm, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Algorithm %d: Data mismatch", algorithm)
+		}
+	}
+}
+
+func TestTrustedPipelineTruncated(t *testing.T) {
+	m := New(Gzip, WithTrustedPipeline())
+	compressed := compressWith(t, m, []byte("truncated trusted stream"))
+
+	_, err := io.ReadAll(m.Reader(bytes.NewReader(compressed[:len(compressed)-3])))
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.Is(err, ErrTruncatedStream) {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestSkipChecksum(t *testing.T) {
+	data := bytes.Repeat([]byte("integrity protected elsewhere "), 100)
+
+	for _, algorithm := range []Algorithm{Gzip, Zlib} {
+		// The writer is unaffected, so the stream stays readable by standard readers
+		m := New(algorithm, WithSkipChecksum())
+		compressed := compressWith(t, m, data)
+		if !bytes.Equal(compressed, compressWith(t, New(algorithm), data)) {
+			t.Fatalf("%v: Expected standard output", algorithm)
+		}
+
+		checksum := len(compressed) - 4
+		if algorithm == Gzip {
+			checksum = len(compressed) - 8
+		}
+		compressed[checksum] ^= 0xff
+
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("%v: Expected checksum to be skipped, got %v", algorithm, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: Data mismatch", algorithm)
+		}
+		if _, err := io.ReadAll(New(algorithm).Reader(bytes.NewReader(compressed))); err == nil {
+			t.Fatalf("%v: Expected checksum error without WithSkipChecksum", algorithm)
+		}
+	}
+}
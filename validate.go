@@ -0,0 +1,82 @@
+package compressionstdlib
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidOption is returned by NewE for options that cannot take effect together
+var ErrInvalidOption = errors.New("invalid compression option")
+
+// NewE is like New, but rejects configurations that would otherwise make
+// Writer panic or silently ignore an option: unknown algorithms, algorithms
+// or backends missing from the build, invalid levels, unregistered dictionary
+// names and conflicting options.
+func NewE(algorithm Algorithm, opts ...Option) (*Middleware, error) {
+	m := New(algorithm, opts...)
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// validate checks that the configuration can create writers and that every option applies
+func (m *Middleware) validate() error {
+	if _, ok := algorithmNames[m.algorithm]; !ok {
+		return fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, m.algorithm)
+	}
+	switch m.algorithm {
+	case Gzip, Zlib, Flate, Bzip2, None, Auto, RLE, LZW:
+	default:
+		if _, ok := codecs[m.algorithm]; !ok {
+			return unsupportedAlgorithm(m.algorithm)
+		}
+	}
+	if m.levelErr != nil {
+		return m.levelErr
+	}
+	if m.backend != BackendStdlib && len(backendCodecs[m.backend]) == 0 {
+		return fmt.Errorf("%w: backend %d is not compiled in, build with -tags klauspost", ErrInvalidOption, m.backend)
+	}
+
+	if m.dictName != "" {
+		if _, ok := lookupDictionary(m.dictName); !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownDictionary, m.dictName)
+		}
+	}
+	if m.dictName != "" || m.dictionary != nil {
+		switch m.algorithm {
+		case Zlib, Flate, Auto:
+		default:
+			return fmt.Errorf("%w: %v does not support preset dictionaries", ErrInvalidOption, m.algorithm)
+		}
+	}
+	if m.originalSize && !m.recordsSize() {
+		return fmt.Errorf("%w: WithOriginalSize applies to plain gzip, zlib and flate streams only", ErrInvalidOption)
+	}
+	if len(m.paddingBuckets) > 0 && !paddable(m.algorithm) && m.algorithm != None && m.algorithm != Auto {
+		return fmt.Errorf("%w: WithPaddingBuckets does not apply to %v streams", ErrInvalidOption, m.algorithm)
+	}
+	if len(m.paddingBuckets) > 0 && m.strictTrailer && m.algorithm != Gzip && paddable(m.algorithm) {
+		return fmt.Errorf("%w: WithStrictTrailer would reject the zero padding of %v streams", ErrInvalidOption, m.algorithm)
+	}
+	if m.seekableFormat && m.algorithm != Gzip {
+		return fmt.Errorf("%w: WithSeekableFormat applies to gzip streams only", ErrInvalidOption)
+	}
+	if m.parallel > 0 && m.algorithm != Gzip {
+		return fmt.Errorf("%w: WithParallel applies to gzip streams only", ErrInvalidOption)
+	}
+	if m.headerCRC && m.algorithm != Gzip {
+		return fmt.Errorf("%w: WithHeaderCRC applies to gzip streams only", ErrInvalidOption)
+	}
+	if m.seekableFormat && m.parallel > 0 {
+		return fmt.Errorf("%w: WithSeekableFormat and WithParallel are mutually exclusive", ErrInvalidOption)
+	}
+	if m.storedPassthrough && !m.seekableFormat && m.parallel == 0 {
+		return fmt.Errorf("%w: WithStoredPassthrough applies to WithSeekableFormat or WithParallel output only", ErrInvalidOption)
+	}
+	if m.singleStream && (m.seekableFormat || m.parallel > 0) {
+		return fmt.Errorf("%w: WithMultistream(false) would stop reading after the first member of WithSeekableFormat or WithParallel output", ErrInvalidOption)
+	}
+	return nil
+}
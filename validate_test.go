@@ -0,0 +1,72 @@
+package compressionstdlib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewE(t *testing.T) {
+	m, err := NewE(Zlib, WithLevel(9), WithDictionary([]byte("dictionary")))
+	if err != nil {
+		t.Fatalf("Expected valid configuration, got %v", err)
+	}
+	if m.algorithm != Zlib || m.level != 9 {
+		t.Fatalf("Options not applied: %+v", m)
+	}
+
+	type testCase struct {
+		name string
+		alg  Algorithm
+		opts []Option
+		want error
+	}
+	tests := []testCase{
+		{"unknown algorithm", Algorithm(99), nil, ErrUnsupportedAlgorithm},
+		{"unregistered dictionary", Zlib, []Option{WithDictionaryName("never-registered")}, ErrUnknownDictionary},
+		{"dictionary with gzip", Gzip, []Option{WithDictionary([]byte("dictionary"))}, ErrInvalidOption},
+		{"seekable and parallel", Gzip, []Option{WithSeekableFormat(), WithParallel(4)}, ErrInvalidOption},
+		{"seekable with zlib", Zlib, []Option{WithSeekableFormat()}, ErrInvalidOption},
+		{"seekable with flate", Flate, []Option{WithSeekableFormat()}, ErrInvalidOption},
+		{"parallel with zlib", Zlib, []Option{WithParallel(4)}, ErrInvalidOption},
+		{"header CRC with zlib", Zlib, []Option{WithHeaderCRC()}, ErrInvalidOption},
+		{"padding with RLE", RLE, []Option{WithPaddingBuckets(1024)}, ErrInvalidOption},
+		{"padding with strict zlib", Zlib, []Option{WithPaddingBuckets(1024), WithStrictTrailer()}, ErrInvalidOption},
+		{"padding with strict flate", Flate, []Option{WithPaddingBuckets(1024), WithStrictTrailer()}, ErrInvalidOption},
+		{"padding with strict LZW", LZW, []Option{WithPaddingBuckets(1024), WithStrictTrailer()}, ErrInvalidOption},
+		{"single stream and seekable", Gzip, []Option{WithSeekableFormat(), WithMultistream(false)}, ErrInvalidOption},
+		{"single stream and parallel", Gzip, []Option{WithParallel(4), WithMultistream(false)}, ErrInvalidOption},
+		{"stored passthrough without blocks", Gzip, []Option{WithStoredPassthrough()}, ErrInvalidOption},
+	}
+	for algorithm := range codecs {
+		tests = append(tests, testCase{"padding with " + algorithm.String(), algorithm, []Option{WithPaddingBuckets(1024)}, ErrInvalidOption})
+	}
+	if _, ok := codecs[Zstd]; !ok {
+		tests = append(tests, testCase{"algorithm not compiled in", Zstd, nil, ErrUnsupportedAlgorithm})
+	}
+	if len(backendCodecs[BackendKlauspost]) == 0 {
+		tests = append(tests, testCase{"backend not compiled in", Gzip, []Option{WithBackend(BackendKlauspost)}, ErrInvalidOption})
+	}
+
+	for _, tt := range tests {
+		m, err := NewE(tt.alg, tt.opts...)
+		if !errors.Is(err, tt.want) {
+			t.Fatalf("%s: Expected %v, got %v", tt.name, tt.want, err)
+		}
+		if m != nil {
+			t.Fatalf("%s: Expected no middleware on error", tt.name)
+		}
+	}
+
+	// Combinations that take effect
+	for name, m := range map[string]func() (*Middleware, error){
+		"padding with strict gzip":      func() (*Middleware, error) { return NewE(Gzip, WithPaddingBuckets(1024), WithStrictTrailer()) },
+		"auto level with stream header": func() (*Middleware, error) { return NewE(Gzip, WithAutoLevel(), WithStreamHeader()) },
+		"header CRC with gzip":          func() (*Middleware, error) { return NewE(Gzip, WithHeaderCRC()) },
+		"single stream with plain gzip": func() (*Middleware, error) { return NewE(Gzip, WithMultistream(false)) },
+		"stored passthrough seekable":   func() (*Middleware, error) { return NewE(Gzip, WithSeekableFormat(), WithStoredPassthrough()) },
+	} {
+		if _, err := m(); err != nil {
+			t.Fatalf("%s: Expected valid configuration, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+
+	"schneider.vip/hybridbuffer/middleware/compressionstdlib/seekable"
+)
+
+// VerifyLevel selects how thoroughly Verify checks a stream
+type VerifyLevel int
+
+const (
+	// VerifyQuick checks stream structure without decompressing: headers
+	// (including the gzip header CRC), and for seekable inputs the presence of a
+	// trailer. Containers written by the seekable package are walked through
+	// their index, checking the header and size trailer of every block.
+	VerifyQuick VerifyLevel = iota
+	// VerifyDeep fully decodes the stream and verifies all checksums
+	VerifyDeep
+)
+
+// Verify checks the integrity of a compressed stream without the caller
+// configuring its algorithm; gzip, zlib, seekable containers and streams with
+// a stream header are detected automatically. It is meant for periodic
+// integrity sweeps, where VerifyQuick keeps the cost low by default.
+func Verify(r io.Reader, level VerifyLevel) error {
+	if level == VerifyQuick {
+		if done, err := verifyContainer(r); done {
+			return err
+		}
+	}
+
+	br := bufio.NewReaderSize(r, headerLimits{}.bufferSize())
+	m, err := detectStream(br)
+	if err != nil {
+		return err
+	}
+
+	if level == VerifyDeep {
+		dr, err := m.decompressor(m.algorithm, br)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(io.Discard, dr)
+		return err
+	}
+	return verifyHeader(br, m.algorithm)
+}
+
+// Verify fully decompresses r with the middleware's configuration, discarding
+// the output, and reports the compressed and decompressed sizes. All checksums
+// are verified and trailing data is rejected, even when the middleware skips
+// checksums or recovers from corruption while reading. It suits background
+// integrity scans of spilled files without materializing their data.
+func (m *Middleware) Verify(r io.Reader) (Stats, error) {
+	d := *m
+	d.trusted = false
+	d.skipChecksum = false
+	d.onSkip = nil
+	d.strictTrailer = true
+	d.closeUnderlying = false
+
+	in := &countingReader{r: r}
+	dr, err := d.NewReader(in)
+	if err != nil {
+		return Stats{CompressedBytes: in.n}, err
+	}
+	n, err := io.Copy(io.Discard, dr)
+	if cerr := dr.Close(); err == nil {
+		err = cerr
+	}
+	return Stats{CompressedBytes: in.n, UncompressedBytes: n}, err
+}
+
+// detectStream identifies the compression of the stream at the start of br,
+// consuming a stream header if present
+func detectStream(br *bufio.Reader) (*Middleware, error) {
+	magic, _ := br.Peek(len(streamMagic))
+	if bytes.Equal(magic, streamMagic) {
+		h, err := readStreamHeader(br)
+		if err != nil {
+			return nil, err
+		}
+		return New(h.algorithm).fromHeader(h)
+	}
+
+	magic, _ = br.Peek(3)
+	algorithm, ok := detectAlgorithm(magic)
+	if !ok {
+		return nil, ErrUnknownFormat
+	}
+	return New(algorithm), nil
+}
+
+// verifyContainer checks seekable containers through their index. It reports
+// whether r was recognized as a container.
+func verifyContainer(r io.Reader) (bool, error) {
+	rs, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	})
+	if !ok {
+		return false, nil
+	}
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, nil
+	}
+	end, err := rs.Seek(0, io.SeekEnd)
+	if _, serr := rs.Seek(start, io.SeekStart); err != nil || serr != nil {
+		return false, nil
+	}
+
+	ra, err := seekable.NewReaderAt(io.NewSectionReader(rs, start, end-start), end-start)
+	if errors.Is(err, seekable.ErrInvalidFooter) {
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	return true, ra.Check()
+}
+
+// verifyHeader validates the stream header structure and, for seekable sources, the stream length
+func verifyHeader(br *bufio.Reader, algorithm Algorithm) error {
+	var n, minSize int
+	switch algorithm {
+	case Gzip:
+		var err error
+		if n, err = parseGzipHeader(br, headerLimits{}); err != nil {
+			return err
+		}
+		if n == 0 {
+			return gzip.ErrHeader
+		}
+		hdr, _ := br.Peek(n)
+		if err := checkHeaderCRC(hdr); err != nil {
+			return fmt.Errorf("%w: header checksum mismatch", err)
+		}
+		minSize = n + 2 + 8 // header, empty deflate block, trailer
+	case Zlib:
+		hdr, _ := br.Peek(2)
+		if _, ok := detectAlgorithm(hdr); !ok {
+			return zlib.ErrHeader
+		}
+		minSize = 2 + 2 + 4
+	}
+
+	// Without decompressing, a stream shorter than header plus trailer must be truncated
+	if _, err := br.Peek(minSize); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != bufio.ErrBufferFull {
+			return err
+		}
+	}
+	return nil
+}
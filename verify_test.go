@@ -0,0 +1,114 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"schneider.vip/hybridbuffer/middleware/compressionstdlib/seekable"
+)
+
+func TestVerify(t *testing.T) {
+	data := bytes.Repeat([]byte("verify me "), 1000)
+
+	for _, alg := range []Algorithm{Gzip, Zlib} {
+		compressed := compressWith(t, New(alg), data)
+		for _, level := range []VerifyLevel{VerifyQuick, VerifyDeep} {
+			if err := Verify(bytes.NewReader(compressed), level); err != nil {
+				t.Fatalf("Algorithm %d level %d: expected valid stream, got %v", alg, level, err)
+			}
+		}
+
+		// Flipping a payload byte is only detected by a deep scan
+		corrupt := append([]byte(nil), compressed...)
+		corrupt[len(corrupt)/2] ^= 0xff
+		if err := Verify(bytes.NewReader(corrupt), VerifyQuick); err != nil {
+			t.Fatalf("Algorithm %d: quick scan should not inflate, got %v", alg, err)
+		}
+		if err := Verify(bytes.NewReader(corrupt), VerifyDeep); err == nil {
+			t.Fatalf("Algorithm %d: expected deep scan to detect corruption", alg)
+		}
+
+		// Truncation below header plus trailer is caught by a quick scan
+		if err := Verify(bytes.NewReader(compressed[:6]), VerifyQuick); err == nil {
+			t.Fatalf("Algorithm %d: expected quick scan to detect truncation", alg)
+		}
+	}
+}
+
+func TestVerifyUnknownFormat(t *testing.T) {
+	err := Verify(bytes.NewReader([]byte("plain text")), VerifyQuick)
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestVerifyLazyStream(t *testing.T) {
+	compressed := compressWith(t, NewLazy(), bytes.Repeat([]byte("text "), 1000))
+	if err := Verify(bytes.NewReader(compressed), VerifyDeep); err != nil {
+		t.Fatalf("Expected valid tagged stream, got %v", err)
+	}
+}
+
+func TestVerifySeekableContainer(t *testing.T) {
+	var buf bytes.Buffer
+	w := seekable.NewWriter(&buf, seekable.WithBlockSize(1024))
+	if _, err := io.Copy(w, bytes.NewReader(bytes.Repeat([]byte("block "), 2000))); err != nil {
+		t.Fatalf("Failed to write container: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close container: %v", err)
+	}
+	container := buf.Bytes()
+
+	if err := Verify(bytes.NewReader(container), VerifyQuick); err != nil {
+		t.Fatalf("Expected valid container, got %v", err)
+	}
+
+	// Corrupting a block trailer is visible through the index without inflating
+	container[1] ^= 0xff
+	if err := Verify(bytes.NewReader(container), VerifyQuick); err == nil {
+		t.Fatal("Expected quick scan to detect corrupted block header")
+	}
+}
+
+func TestMiddlewareVerify(t *testing.T) {
+	data := bytes.Repeat([]byte("background integrity scan "), 2000)
+
+	for _, m := range []*Middleware{New(Gzip), New(Zlib), New(Flate), New(LZW), New(Gzip, WithParallel(2))} {
+		compressed := compressWith(t, m, data)
+		stats, err := m.Verify(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("%v: Expected valid stream, got %v", m.algorithm, err)
+		}
+		if stats.CompressedBytes != int64(len(compressed)) || stats.UncompressedBytes != int64(len(data)) {
+			t.Fatalf("%v: Unexpected stats %+v", m.algorithm, stats)
+		}
+		if stats.Ratio() >= 1 {
+			t.Fatalf("%v: Expected ratio below 1, got %f", m.algorithm, stats.Ratio())
+		}
+	}
+}
+
+func TestMiddlewareVerify_Damaged(t *testing.T) {
+	data := bytes.Repeat([]byte("background integrity scan "), 2000)
+	m := New(Gzip, WithSkipChecksum(), WithCorruptionRecovery(func(SkippedRange) {}))
+	compressed := compressWith(t, m, data)
+
+	// Checksums are verified even though the middleware skips them when reading
+	corrupted := bytes.Clone(compressed)
+	corrupted[len(corrupted)-5] ^= 0xff
+	if _, err := m.Verify(bytes.NewReader(corrupted)); !errors.Is(err, ErrCorruptedStream) {
+		t.Fatalf("Expected ErrCorruptedStream, got %v", err)
+	}
+
+	if _, err := m.Verify(bytes.NewReader(compressed[:len(compressed)-3])); !errors.Is(err, ErrTruncatedStream) {
+		t.Fatalf("Expected ErrTruncatedStream, got %v", err)
+	}
+
+	trailing := append(bytes.Clone(compressed), "garbage"...)
+	if _, err := m.Verify(bytes.NewReader(trailing)); !errors.Is(err, ErrTrailingData) {
+		t.Fatalf("Expected ErrTrailingData, got %v", err)
+	}
+}
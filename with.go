@@ -0,0 +1,20 @@
+package compressionstdlib
+
+// With returns a copy of m with opts applied on top of its configuration,
+// leaving m unchanged. A base middleware can thus be shared and specialized
+// per call site without data races. The copy shares the stream slots of
+// WithMaxConcurrentStreams and the segment state of WithSegmentSeeding with
+// m, unless opts replace them.
+func (m *Middleware) With(opts ...Option) *Middleware {
+	c := *m
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// WithLevel returns a copy of m using the given compression level; see the
+// WithLevel option
+func (m *Middleware) WithLevel(level int) *Middleware {
+	return m.With(WithLevel(level))
+}
@@ -0,0 +1,44 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestMiddlewareWith(t *testing.T) {
+	base := New(Zlib, WithMaxDecompressedSize(1<<20))
+
+	best := base.WithLevel(BestCompression)
+	if best.level != 9 || base.level != 6 {
+		t.Fatalf("Expected levels 9 and 6, got %d and %d", best.level, base.level)
+	}
+	if best.maxDecompressed != 1<<20 {
+		t.Fatal("Expected the copy to keep the base configuration")
+	}
+
+	strict := base.With(WithStrictTrailer(), WithLevel(BestSpeed))
+	if !strict.strictTrailer || strict.level != 1 || base.strictTrailer {
+		t.Fatalf("Unexpected configurations %+v and %+v", strict, base)
+	}
+
+	// Specializing a shared middleware concurrently is safe
+	data := bytes.Repeat([]byte("per call site "), 200)
+	var wg sync.WaitGroup
+	for level := 1; level <= 9; level++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := base.WithLevel(level)
+			var buf bytes.Buffer
+			w := m.Writer(&buf).(io.WriteCloser)
+			w.Write(data)
+			w.Close()
+			if got, err := io.ReadAll(m.Reader(&buf)); err != nil || !bytes.Equal(got, data) {
+				t.Errorf("Level %d: failed to read: %v", level, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
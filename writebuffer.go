@@ -0,0 +1,53 @@
+package compressionstdlib
+
+import (
+	"bufio"
+	"io"
+)
+
+// WithWriteBufferSize collects the compressed output in a buffer of n bytes
+// before passing it to the wrapped writer, so compressors emitting many small
+// blocks cause fewer writes, and fewer syscalls when spilling to files. The
+// buffer is written through on Flush and Close. Values of zero or below
+// disable buffering.
+func WithWriteBufferSize(n int) Option {
+	return func(m *Middleware) {
+		m.writeBufferSize = max(n, 0)
+	}
+}
+
+// bufferedWriter buffers the output of a compressor
+type bufferedWriter struct {
+	*bufio.Writer
+	w io.Writer
+}
+
+// buffering returns the destination of the compressor writing to w
+func (m *Middleware) buffering(w io.Writer) io.Writer {
+	if m.writeBufferSize == 0 {
+		return w
+	}
+	return &bufferedWriter{Writer: bufio.NewWriterSize(w, m.writeBufferSize), w: w}
+}
+
+// Flush writes the buffered output through to the wrapped writer and flushes it
+func (b *bufferedWriter) Flush() error {
+	if err := b.Writer.Flush(); err != nil {
+		return err
+	}
+	return flush(b.w)
+}
+
+// Reset discards the buffered output and directs further output to w
+func (b *bufferedWriter) Reset(w io.Writer) {
+	b.Writer.Reset(w)
+	b.w = w
+}
+
+// drain writes the output buffered in w, if any, to the wrapped writer
+func drain(w io.Writer) error {
+	if b, ok := w.(*bufferedWriter); ok {
+		return b.Writer.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// writeRecorder records the size of each write
+type writeRecorder struct {
+	bytes.Buffer
+	sizes []int
+}
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	w.sizes = append(w.sizes, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestWriteBufferSize(t *testing.T) {
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	for i := range data {
+		data[i] %= 16
+	}
+
+	const size = 64 << 10
+	for _, alg := range []Algorithm{Gzip, Zlib, Flate} {
+		m := New(alg, WithWriteBufferSize(size), WithFlushEvery(100<<10))
+		var dst writeRecorder
+		w := m.Writer(&dst).(io.WriteCloser)
+		for off := 0; off < len(data); off += 4096 {
+			if _, err := w.Write(data[off : off+4096]); err != nil {
+				t.Fatalf("%v: failed to write: %v", alg, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%v: failed to close: %v", alg, err)
+		}
+
+		// Only flushes write partial buffers
+		flushes := len(data)/(100<<10) + 1
+		small := 0
+		for _, n := range dst.sizes {
+			if n < size {
+				small++
+			}
+		}
+		if small > flushes {
+			t.Fatalf("%v: expected at most %d writes below the buffer size, got %d of %d", alg, flushes, small, len(dst.sizes))
+		}
+
+		got, err := io.ReadAll(m.Reader(&dst.Buffer))
+		if err != nil {
+			t.Fatalf("%v: failed to read: %v", alg, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%v: data mismatch", alg)
+		}
+	}
+}
+
+func TestWriteBufferSize_Flush(t *testing.T) {
+	m := New(Zlib, WithWriteBufferSize(1<<16))
+	var dst bytes.Buffer
+	w := m.Writer(&dst)
+	if _, err := w.Write([]byte("buffered")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if dst.Len() != 0 {
+		t.Fatalf("Expected no output before Flush, got %d bytes", dst.Len())
+	}
+	if err := w.(Flusher).Flush(); err != nil {
+		t.Fatalf("Failed to flush: %v", err)
+	}
+	got := make([]byte, len("buffered"))
+	if _, err := io.ReadFull(New(Zlib).Reader(bytes.NewReader(dst.Bytes())), got); err != nil || string(got) != "buffered" {
+		t.Fatalf("Expected flushed data to be readable, got %q (%v)", got, err)
+	}
+
+	// The resettable writer buffers as well and reuses the buffer
+	rw, err := m.NewResettableWriter(io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to create resettable writer: %v", err)
+	}
+	for range 2 {
+		dst.Reset()
+		rw.Reset(&dst)
+		rw.Write([]byte("again"))
+		if err := rw.Close(); err != nil {
+			t.Fatalf("Failed to close: %v", err)
+		}
+		if got, err := io.ReadAll(m.Reader(&dst)); err != nil || string(got) != "again" {
+			t.Fatalf("Expected %q, got %q (%v)", "again", got, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build xz
+
+package compressionstdlib
+
+import (
+	"io"
+
+	"github.com/ulikunitz/xz"
+)
+
+func init() {
+	registerCodec(XZ, codec{
+		newWriter: func(w io.Writer, level int) (io.WriteCloser, error) {
+			// The dictionary size grows with the level: 256KB at level 1,
+			// the xz default of 8MB at 6 and 64MB at 9, like xz -9
+			return xz.WriterConfig{DictCap: 1 << (17 + level)}.NewWriter(w)
+		},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			xr, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(xr), nil
+		},
+	})
+}
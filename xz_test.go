@@ -0,0 +1,30 @@
+//go:build xz
+
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestXZRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("xz backend "), 5000)
+	for _, level := range []int{1, 6} {
+		m, err := NewE(XZ, WithLevel(level))
+		if err != nil {
+			t.Fatalf("Level %d: %v", level, err)
+		}
+		compressed := compressWith(t, m, data)
+		if !bytes.HasPrefix(compressed, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}) {
+			t.Fatalf("Level %d: expected xz magic, got % x", level, compressed[:6])
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("Level %d: read failed: %v", level, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Level %d: data mismatch", level)
+		}
+	}
+}
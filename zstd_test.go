@@ -0,0 +1,33 @@
+//go:build zstd
+
+package compressionstdlib
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestZstdRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("zstd backend "), 5000)
+	for _, level := range []int{1, 5, 8, 9} {
+		m, err := NewE(Zstd, WithLevel(level))
+		if err != nil {
+			t.Fatalf("Level %d: %v", level, err)
+		}
+		compressed := compressWith(t, m, data)
+		if !bytes.HasPrefix(compressed, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+			t.Fatalf("Level %d: expected zstd magic, got % x", level, compressed[:4])
+		}
+		if len(compressed) >= len(data)/10 {
+			t.Fatalf("Level %d: expected compression, got %d bytes for %d", level, len(compressed), len(data))
+		}
+		got, err := io.ReadAll(m.Reader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatalf("Level %d: read failed: %v", level, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("Level %d: data mismatch", level)
+		}
+	}
+}